@@ -46,7 +46,8 @@ func main() {
 		CheckQuery:                  true,
 		CheckBody:                   true,
 		CheckBodyOnlyForContentType: "application/x-www-form-urlencoded",
-		Whitelist:                   []string{"sortBy", "limit", "page", "sortOrder", "name", "description", "total_expense", "amount", "transaction_type", "category", "amount", "status"},
+		Whitelist:                   []string{"sortBy", "limit", "page", "sortOrder", "name", "description", "total_expense", "amount", "transaction_type", "category", "amount", "status", "from", "to", "min_amount", "max_amount", "reference", "cursor", "sort", "search", "format", "email", "q", "type", "month", "include_archived"},
+		WhitelistPrefixes:           []string{"filter["},
 	}
 
 	router := routers.MainRouter()
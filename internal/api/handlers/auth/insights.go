@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// myShares yields one row per expense the user had a share of: their split,
+// or for expenses they paid, whatever was not split out to others.
+// It takes (userID, from, to) twice.
+const myShares = `
+	SELECT e.group_id, e.created_at, s.share_amount AS share
+	FROM group_expense_splits s
+	JOIN group_expenses e ON e.id = s.expense_id
+	WHERE s.owed_by = ? AND e.created_at >= ? AND e.created_at < ?
+	UNION ALL
+	SELECT e.group_id, e.created_at,
+		e.amount - COALESCE((SELECT SUM(s.share_amount) FROM group_expense_splits s WHERE s.expense_id = e.id), 0) AS share
+	FROM group_expenses e
+	WHERE e.paid_by = ? AND e.created_at >= ? AND e.created_at < ?`
+
+type monthlySpend struct {
+	Month string          `json:"month"`
+	Spent decimal.Decimal `json:"spent"`
+}
+
+type groupSpend struct {
+	GroupID   int             `json:"group_id"`
+	GroupName string          `json:"group_name"`
+	Spent     decimal.Decimal `json:"spent"`
+	Expenses  int             `json:"expenses"`
+}
+
+type settleSpeed struct {
+	Payments        int      `json:"payments"`
+	AverageHours    *float64 `json:"average_hours"`
+	FastestHours    *float64 `json:"fastest_hours"`
+	SlowestHours    *float64 `json:"slowest_hours"`
+	UnsettledSplits int      `json:"unsettled_splits"`
+}
+
+type outstandingGroup struct {
+	GroupID   int             `json:"group_id"`
+	GroupName string          `json:"group_name"`
+	YouOwe    decimal.Decimal `json:"you_owe"`
+	OwedToYou decimal.Decimal `json:"owed_to_you"`
+}
+
+func roundedHours(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	hours := decimal.NewFromFloat(v.Float64).Round(2).InexactFloat64()
+	return &hours
+}
+
+// FUNC TO GET THE LOGGED-IN USER'S SPENDING INSIGHTS ACROSS ALL GROUPS
+func GetMyInsightsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	// the period is [from, to] inclusive; defaults to the last six months
+	var err error
+	to, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			utils.WriteError(w, "to must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, -6, 0)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			utils.WriteError(w, "from must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		utils.WriteError(w, "to cannot be before from", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		utils.WriteError(w, "period cannot be longer than one year", http.StatusBadRequest)
+		return
+	}
+
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+	shareArgs := []interface{}{userID, fromStr, toStr, userID, fromStr, toStr}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	totalSpent := decimal.Zero
+	byMonth := make([]monthlySpend, 0)
+	rows, err := db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(created_at, '%Y-%m') AS month, SUM(share)
+		FROM (`+myShares+`) AS shares
+		GROUP BY month
+		ORDER BY month
+	`, shareArgs...)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch monthly spending: %v", err)
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m monthlySpend
+		if err := rows.Scan(&m.Month, &m.Spent); err != nil {
+			utils.WriteError(w, "error reading insights", http.StatusInternalServerError)
+			return
+		}
+		totalSpent = totalSpent.Add(m.Spent)
+		byMonth = append(byMonth, m)
+	}
+
+	topGroups := make([]groupSpend, 0)
+	groupRows, err := db.QueryContext(ctx, `
+		SELECT shares.group_id, g.name, SUM(shares.share) AS spent, COUNT(*)
+		FROM (`+myShares+`) AS shares
+		JOIN groups g ON g.id = shares.group_id
+		GROUP BY shares.group_id, g.name
+		ORDER BY spent DESC
+		LIMIT 5
+	`, shareArgs...)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch spending by group: %v", err)
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+	defer groupRows.Close()
+
+	for groupRows.Next() {
+		var g groupSpend
+		if err := groupRows.Scan(&g.GroupID, &g.GroupName, &g.Spent, &g.Expenses); err != nil {
+			utils.WriteError(w, "error reading insights", http.StatusInternalServerError)
+			return
+		}
+		topGroups = append(topGroups, g)
+	}
+
+	// settlement speed is the time from an expense being logged to each payment the user made towards it
+	var speed settleSpeed
+	var avgHours, minHours, maxHours sql.NullFloat64
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*),
+			AVG(TIMESTAMPDIFF(SECOND, e.created_at, p.created_at)) / 3600,
+			MIN(TIMESTAMPDIFF(SECOND, e.created_at, p.created_at)) / 3600,
+			MAX(TIMESTAMPDIFF(SECOND, e.created_at, p.created_at)) / 3600
+		FROM split_payments p
+		JOIN group_expense_splits s ON s.id = p.split_id
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE p.payer_id = ? AND p.status = 'completed' AND p.created_at >= ? AND p.created_at < ?
+	`, userID, fromStr, toStr).Scan(&speed.Payments, &avgHours, &minHours, &maxHours)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch settlement speed: %v", err)
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+	speed.AverageHours = roundedHours(avgHours)
+	speed.FastestHours = roundedHours(minHours)
+	speed.SlowestHours = roundedHours(maxHours)
+
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_expense_splits WHERE owed_by = ? AND is_settled = FALSE", userID).
+		Scan(&speed.UnsettledSplits)
+	if err != nil {
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+
+	// outstanding balances are as of now, regardless of the period
+	youOwe, owedToYou := decimal.Zero, decimal.Zero
+	outstanding := make([]outstandingGroup, 0)
+	outRows, err := db.QueryContext(ctx, `
+		SELECT g.id, g.name,
+			COALESCE(SUM(CASE WHEN s.owed_by = ? THEN s.amount_owed END), 0) AS you_owe,
+			COALESCE(SUM(CASE WHEN e.paid_by = ? THEN s.amount_owed END), 0) AS owed_to_you
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		JOIN groups g ON g.id = e.group_id
+		WHERE s.is_settled = FALSE AND (s.owed_by = ? OR e.paid_by = ?)
+		GROUP BY g.id, g.name
+		ORDER BY g.name
+	`, userID, userID, userID, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch outstanding balances: %v", err)
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+	defer outRows.Close()
+
+	for outRows.Next() {
+		var o outstandingGroup
+		if err := outRows.Scan(&o.GroupID, &o.GroupName, &o.YouOwe, &o.OwedToYou); err != nil {
+			utils.WriteError(w, "error reading insights", http.StatusInternalServerError)
+			return
+		}
+		youOwe = youOwe.Add(o.YouOwe)
+		owedToYou = owedToYou.Add(o.OwedToYou)
+		outstanding = append(outstanding, o)
+	}
+
+	// wallet movement for split settlements in the period
+	paidOut, received := decimal.Zero, decimal.Zero
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN transaction_type = 'debit' THEN amount END), 0),
+			COALESCE(SUM(CASE WHEN transaction_type = 'credit' THEN amount END), 0)
+		FROM transactions
+		WHERE user_id = ? AND category = 'split' AND status = 'success' AND created_at >= ? AND created_at < ?
+	`, userID, fromStr, toStr).Scan(&paidOut, &received)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch settlement transactions: %v", err)
+		utils.WriteError(w, "failed to fetch insights", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"from":         fromStr,
+			"to":           to.AddDate(0, 0, -1).Format("2006-01-02"),
+			"total_spent":  totalSpent,
+			"by_month":     byMonth,
+			"top_groups":   topGroups,
+			"settle_speed": speed,
+			"settlements": map[string]interface{}{
+				"paid_out": paidOut,
+				"received": received,
+			},
+			"outstanding": map[string]interface{}{
+				"you_owe":     youOwe,
+				"owed_to_you": owedToYou,
+				"net":         owedToYou.Sub(youOwe),
+				"by_group":    outstanding,
+			},
+		},
+	})
+}
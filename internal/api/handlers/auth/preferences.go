@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func fetchPreferences(ctx context.Context, db *sql.DB, userID int) (models.UserPreferences, error) {
+	prefs := models.UserPreferences{UserID: userID, AutoSettleFloor: decimal.Zero, MonthlyDigestEnabled: true}
+	err := db.QueryRowContext(ctx, `
+		SELECT auto_settle_enabled, auto_settle_floor, monthly_digest_enabled, created_at, updated_at
+		FROM user_preferences WHERE user_id = ?
+	`, userID).Scan(&prefs.AutoSettleEnabled, &prefs.AutoSettleFloor, &prefs.MonthlyDigestEnabled, &prefs.CreatedAt, &prefs.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return prefs, utils.ErrorHandler(err, "failed to fetch preferences")
+	}
+	return prefs, nil
+}
+
+// FUNC TO GET THE LOGGED-IN USER'S PREFERENCES
+func GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	prefs, err := fetchPreferences(ctx, db, userID)
+	if err != nil {
+		utils.WriteError(w, "failed to fetch preferences", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data":   prefs,
+	})
+}
+
+// FUNC TO UPDATE THE LOGGED-IN USER'S PREFERENCES
+func UpdatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		AutoSettleEnabled    *bool            `json:"auto_settle_enabled"`
+		AutoSettleFloor      *decimal.Decimal `json:"auto_settle_floor"`
+		MonthlyDigestEnabled *bool            `json:"monthly_digest_enabled"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.AutoSettleEnabled == nil && req.AutoSettleFloor == nil && req.MonthlyDigestEnabled == nil {
+		utils.WriteError(w, "no updates provided", http.StatusBadRequest)
+		return
+	}
+
+	if req.AutoSettleFloor != nil && req.AutoSettleFloor.LessThan(decimal.Zero) {
+		utils.WriteError(w, "auto_settle_floor cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	prefs, err := fetchPreferences(ctx, db, userID)
+	if err != nil {
+		utils.WriteError(w, "failed to fetch preferences", http.StatusInternalServerError)
+		return
+	}
+
+	if req.AutoSettleEnabled != nil {
+		prefs.AutoSettleEnabled = *req.AutoSettleEnabled
+	}
+	if req.AutoSettleFloor != nil {
+		prefs.AutoSettleFloor = *req.AutoSettleFloor
+	}
+	if req.MonthlyDigestEnabled != nil {
+		prefs.MonthlyDigestEnabled = *req.MonthlyDigestEnabled
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO user_preferences (user_id, auto_settle_enabled, auto_settle_floor, monthly_digest_enabled) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE auto_settle_enabled = VALUES(auto_settle_enabled), auto_settle_floor = VALUES(auto_settle_floor),
+			monthly_digest_enabled = VALUES(monthly_digest_enabled)
+	`, userID, prefs.AutoSettleEnabled, prefs.AutoSettleFloor, prefs.MonthlyDigestEnabled)
+	if err != nil {
+		utils.Logger.Errorf("failed to update preferences: %v", err)
+		utils.WriteError(w, "failed to update preferences", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "preferences updated successfully",
+		"data":    prefs,
+	})
+}
@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+var errInvalidPinOtp = errors.New("invalid or expired otp")
+
+func hashPinOtp(otp string) string {
+	hashed := sha256.Sum256([]byte(otp))
+	return hex.EncodeToString(hashed[:])
+}
+
+// consumePinOtp validates the PIN OTP for a user and clears it so it can only be used once
+func consumePinOtp(ctx context.Context, db *sql.DB, userID int, otp string) error {
+	var pin models.TransactionPin
+	err := db.QueryRowContext(ctx, "SELECT otp, otp_expires FROM transaction_pins WHERE user_id = ?", userID).
+		Scan(&pin.Otp, &pin.OtpExpires)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errInvalidPinOtp
+		}
+		return utils.ErrorHandler(err, "failed to fetch pin otp")
+	}
+
+	if !pin.Otp.Valid || !pin.OtpExpires.Valid || pin.Otp.String != hashPinOtp(otp) {
+		return errInvalidPinOtp
+	}
+
+	if pin.OtpExpires.String <= time.Now().Format(time.RFC3339) {
+		return errInvalidPinOtp
+	}
+
+	_, err = db.ExecContext(ctx, "UPDATE transaction_pins SET otp = NULL, otp_expires = NULL WHERE user_id = ?", userID)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to clear pin otp")
+	}
+
+	return nil
+}
+
+func savePin(ctx context.Context, db *sql.DB, userID int, pin string) error {
+	hashedPin, err := utils.HashPassword(pin)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `
+		UPDATE transaction_pins SET pin_hash = ?, failed_attempts = 0, locked_until = NULL WHERE user_id = ?
+	`, hashedPin, userID)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to save transaction pin")
+	}
+
+	return nil
+}
+
+// FUNC TO REQUEST AN OTP FOR SETTING, CHANGING OR RESETTING THE TRANSACTION PIN
+func RequestPinOtpHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := db.QueryRowContext(ctx, "SELECT email, username FROM users WHERE id = ?", userID).Scan(&user.Email, &user.Username)
+	if err != nil {
+		utils.WriteError(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	duration, err := strconv.Atoi(os.Getenv("OTP_TOKEN_EXP_DURATION"))
+	if err != nil {
+		utils.Logger.Error("failed to read OTP_TOKEN_EXP_DURATION")
+		utils.WriteError(w, "failed to generate otp", http.StatusInternalServerError)
+		return
+	}
+
+	mins := time.Duration(duration)
+	expiryTime := time.Now().Add(mins * time.Minute)
+	expiryStr := expiryTime.Format(time.RFC3339)
+
+	otp, err := utils.GenerateSecureOTP()
+	if err != nil {
+		utils.Logger.Errorf("failed to generate otp: %v", err)
+		utils.WriteError(w, "failed to generate otp", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO transaction_pins (user_id, otp, otp_expires) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE otp = VALUES(otp), otp_expires = VALUES(otp_expires)
+	`, userID, hashPinOtp(otp), expiryStr)
+	if err != nil {
+		utils.Logger.Errorf("failed to save pin otp: %v", err)
+		utils.WriteError(w, "failed to generate otp", http.StatusInternalServerError)
+		return
+	}
+
+	go func(email, username, otp string, expiry time.Time) {
+		if err := utils.SendOTPEmail(email, username, otp, expiry); err != nil {
+			utils.Logger.Errorf("failed to send pin OTP email to %s: %v", email, err)
+		}
+	}(user.Email, user.Username, otp, expiryTime)
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "OTP sent to your email",
+	})
+}
+
+// FUNC TO SET THE TRANSACTION PIN FOR THE FIRST TIME
+func SetPinHandler(w http.ResponseWriter, r *http.Request) {
+	savePinWithOtp(w, r, false)
+}
+
+// FUNC TO RESET A FORGOTTEN OR LOCKED TRANSACTION PIN
+func ResetPinHandler(w http.ResponseWriter, r *http.Request) {
+	savePinWithOtp(w, r, true)
+}
+
+func savePinWithOtp(w http.ResponseWriter, r *http.Request, isReset bool) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Otp        string `json:"otp"`
+		Pin        string `json:"pin"`
+		ConfirmPin string `json:"confirm_pin"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Otp == "" || req.Pin == "" || req.ConfirmPin == "" {
+		utils.WriteError(w, "otp, pin and confirm_pin are required", http.StatusBadRequest)
+		return
+	}
+
+	if req.Pin != req.ConfirmPin {
+		utils.WriteError(w, "pins should match", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidatePinFormat(req.Pin); err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var pinHash sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT pin_hash FROM transaction_pins WHERE user_id = ?", userID).Scan(&pinHash)
+	if err != nil && err != sql.ErrNoRows {
+		utils.Logger.Errorf("failed to fetch transaction pin: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !isReset && pinHash.Valid {
+		utils.WriteError(w, "transaction pin already set, use the change or reset endpoint", http.StatusConflict)
+		return
+	}
+
+	if err := consumePinOtp(ctx, db, userID, req.Otp); err != nil {
+		if errors.Is(err, errInvalidPinOtp) {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := savePin(ctx, db, userID, req.Pin); err != nil {
+		utils.WriteError(w, "failed to save transaction pin", http.StatusInternalServerError)
+		return
+	}
+
+	message := "transaction pin set successfully"
+	if isReset {
+		message = "transaction pin reset successfully"
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": message,
+	})
+}
+
+// FUNC TO CHANGE THE TRANSACTION PIN
+func ChangePinHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Otp        string `json:"otp"`
+		CurrentPin string `json:"current_pin"`
+		NewPin     string `json:"new_pin"`
+		ConfirmPin string `json:"confirm_pin"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Otp == "" || req.CurrentPin == "" || req.NewPin == "" || req.ConfirmPin == "" {
+		utils.WriteError(w, "please enter all fields", http.StatusBadRequest)
+		return
+	}
+
+	if req.NewPin != req.ConfirmPin {
+		utils.WriteError(w, "pins should match", http.StatusBadRequest)
+		return
+	}
+
+	if err := utils.ValidatePinFormat(req.NewPin); err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := handlers.VerifyTransactionPin(ctx, db, userID, req.CurrentPin); err != nil {
+		handlers.WritePinError(w, err)
+		return
+	}
+
+	if err := consumePinOtp(ctx, db, userID, req.Otp); err != nil {
+		if errors.Is(err, errInvalidPinOtp) {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := savePin(ctx, db, userID, req.NewPin); err != nil {
+		utils.WriteError(w, "failed to save transaction pin", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "transaction pin changed successfully",
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/api/handlers/groups"
 	"qiyana_paybuddy/internal/models"
 	"qiyana_paybuddy/internal/repositories/sqlconnect"
 	"qiyana_paybuddy/pkg/utils"
@@ -219,11 +220,27 @@ func ConfirmOtpHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// invitations sent before the user signed up are accepted now that the email is proven
+	joinedGroups, err := groups.AcceptPendingInvites(r.Context(), db, user.ID, user.Email)
+	if err != nil {
+		utils.Logger.Errorf("failed to accept pending invites for user %d: %v", user.ID, err)
+		joinedGroups = []int{}
+	}
+
+	// groups that tracked the user as a guest invite them; their debts move over only on accepting
+	guestGroups, err := groups.InviteGuestUser(r.Context(), db, user.ID, user.Email)
+	if err != nil {
+		utils.Logger.Errorf("failed to invite guest user %d: %v", user.ID, err)
+		guestGroups = []int{}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "success",
-		"message": "OTP verified successfully, Welcome onboard!",
+		"status":        "success",
+		"message":       "OTP verified successfully, Welcome onboard!",
+		"joined_groups": joinedGroups,
+		"guest_invites": guestGroups,
 	})
 }
 
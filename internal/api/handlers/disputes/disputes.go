@@ -0,0 +1,697 @@
+package disputes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const disputeColumns = `
+	d.id, d.subject_type, d.subject_id, d.opened_by, d.counterparty_id, d.group_id, d.split_id, d.amount,
+	d.reason, d.evidence, d.status, d.response, d.responded_at, d.resolution_note, d.resolved_by,
+	d.resolved_at, d.reversal_payment_id, d.created_at, d.updated_at`
+
+func scanDispute(row interface{ Scan(...interface{}) error }, d *models.Dispute) error {
+	return row.Scan(&d.ID, &d.SubjectType, &d.SubjectID, &d.OpenedBy, &d.CounterpartyID, &d.GroupID, &d.SplitID, &d.Amount,
+		&d.Reason, &d.Evidence, &d.Status, &d.Response, &d.RespondedAt, &d.ResolutionNote, &d.ResolvedBy,
+		&d.ResolvedAt, &d.ReversalPaymentID, &d.CreatedAt, &d.UpdatedAt)
+}
+
+// loadDispute fetches a dispute by id
+func loadDispute(ctx context.Context, db *sql.DB, disputeID int) (*models.Dispute, error) {
+	var d models.Dispute
+	row := db.QueryRowContext(ctx, `SELECT `+disputeColumns+` FROM disputes d WHERE d.id = ?`, disputeID)
+	if err := scanDispute(row, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// isDisputeAdmin reports whether the user is an admin of the group the dispute belongs to
+func isDisputeAdmin(ctx context.Context, db *sql.DB, d *models.Dispute, userID int) (bool, error) {
+	if !d.GroupID.Valid {
+		return false, nil
+	}
+	return handlers.IsGroupAdmin(ctx, db, int(d.GroupID.Int64), userID)
+}
+
+// notifyDispute emails each user once, skipping the user who made the change
+func notifyDispute(ctx context.Context, db *sql.DB, d *models.Dispute, actorID int, userIDs []int, title, message string) {
+	seen := map[int]bool{actorID: true}
+	for _, id := range userIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var email, firstName string
+		if err := db.QueryRowContext(ctx, "SELECT email, first_name FROM users WHERE id = ?", id).Scan(&email, &firstName); err != nil {
+			utils.Logger.Errorf("failed to load user %d for dispute notification: %v", id, err)
+			continue
+		}
+
+		go func(email, firstName string) {
+			if err := utils.SendDisputeEmail(email, firstName, title, message, d.Amount.StringFixed(2), d.ID); err != nil {
+				utils.Logger.Errorf("failed to send dispute email to %s: %v", email, err)
+			}
+		}(email, firstName)
+	}
+}
+
+func isOpen(status string) bool {
+	return status == "open" || status == "responded"
+}
+
+// FUNC TO OPEN A DISPUTE ON A TRANSACTION OR EXPENSE SPLIT
+func CreateDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		SubjectType string `json:"subject_type"`
+		SubjectID   int    `json:"subject_id"`
+		Reason      string `json:"reason"`
+		Evidence    string `json:"evidence"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len(req.Reason) > 500 {
+		utils.WriteError(w, "reason is required and must be at most 500 characters", http.StatusBadRequest)
+		return
+	}
+
+	if req.SubjectID <= 0 {
+		utils.WriteError(w, "subject_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	d := models.Dispute{SubjectType: req.SubjectType, SubjectID: req.SubjectID, OpenedBy: userID, Reason: req.Reason}
+	if req.Evidence != "" {
+		d.Evidence = sql.NullString{String: req.Evidence, Valid: true}
+	}
+
+	switch req.SubjectType {
+	case "transaction":
+		var ownerID int
+		var relatedType sql.NullString
+		var relatedID sql.NullInt64
+		err := db.QueryRowContext(ctx, `
+			SELECT user_id, amount, counterparty_user_id, group_id, related_type, related_id
+			FROM transactions WHERE id = ?
+		`, req.SubjectID).Scan(&ownerID, &d.Amount, &d.CounterpartyID, &d.GroupID, &relatedType, &relatedID)
+		if err != nil || ownerID != userID {
+			if err != nil && err != sql.ErrNoRows {
+				utils.Logger.Errorf("error fetching disputed transaction: %v", err)
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			utils.WriteError(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		// with no other party and no group admin, nobody could ever decide it
+		if !d.CounterpartyID.Valid && !d.GroupID.Valid {
+			utils.WriteError(w, "this transaction has no other party to dispute it with", http.StatusBadRequest)
+			return
+		}
+
+		switch relatedType.String {
+		case "split":
+			d.SplitID = relatedID
+		case "split_payment":
+			var splitID int64
+			if err := db.QueryRowContext(ctx, "SELECT split_id FROM split_payments WHERE id = ?", relatedID.Int64).Scan(&splitID); err == nil {
+				d.SplitID = sql.NullInt64{Int64: splitID, Valid: true}
+			}
+		}
+
+	case "split":
+		var owedBy, paidBy int
+		var groupID int64
+		err := db.QueryRowContext(ctx, `
+			SELECT s.owed_by, e.paid_by, e.group_id,
+				s.amount_owed + COALESCE((SELECT SUM(p.amount) FROM split_payments p WHERE p.split_id = s.id AND p.status = 'completed'), 0)
+			FROM group_expense_splits s
+			JOIN group_expenses e ON e.id = s.expense_id
+			WHERE s.id = ? AND s.owed_by IS NOT NULL
+		`, req.SubjectID).Scan(&owedBy, &paidBy, &groupID, &d.Amount)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				utils.WriteError(w, "expense split not found", http.StatusNotFound)
+				return
+			}
+			utils.Logger.Errorf("error fetching disputed split: %v", err)
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		counterparty := paidBy
+		if userID == paidBy {
+			counterparty = owedBy
+		} else if userID != owedBy {
+			utils.WriteError(w, "you are not a party to this expense split", http.StatusForbidden)
+			return
+		}
+		d.CounterpartyID = sql.NullInt64{Int64: int64(counterparty), Valid: true}
+		d.GroupID = sql.NullInt64{Int64: groupID, Valid: true}
+		d.SplitID = sql.NullInt64{Int64: int64(req.SubjectID), Valid: true}
+
+	default:
+		utils.WriteError(w, "subject_type must be transaction or split", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM disputes WHERE subject_type = ? AND subject_id = ? AND status IN ('open', 'responded'))
+	`, d.SubjectType, d.SubjectID).Scan(&exists)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		utils.WriteError(w, "there is already an open dispute for this item", http.StatusConflict)
+		return
+	}
+
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO disputes (subject_type, subject_id, opened_by, counterparty_id, group_id, split_id, amount, reason, evidence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.SubjectType, d.SubjectID, d.OpenedBy, d.CounterpartyID, d.GroupID, d.SplitID, d.Amount, d.Reason, d.Evidence)
+	if err != nil {
+		utils.Logger.Errorf("failed to create dispute: %v", err)
+		utils.WriteError(w, "failed to open dispute", http.StatusInternalServerError)
+		return
+	}
+	id, _ := res.LastInsertId()
+	d.ID = int(id)
+	d.Status = "open"
+
+	recipients := []int{int(d.CounterpartyID.Int64)}
+	if d.GroupID.Valid {
+		adminIDs, err := handlers.GroupAdminIDs(ctx, db, int(d.GroupID.Int64))
+		if err != nil {
+			utils.Logger.Errorf("failed to load group admins for dispute %d: %v", d.ID, err)
+		}
+		recipients = append(recipients, adminIDs...)
+	}
+
+	var openerName string
+	db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", userID).Scan(&openerName)
+	notifyDispute(ctx, db, &d, userID, recipients, "New Dispute Opened",
+		fmt.Sprintf("<b>%s</b> has opened a dispute on %s #%d.<br><br>Reason: %s", openerName, d.SubjectType, d.SubjectID, html.EscapeString(d.Reason)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "dispute opened",
+		"data":    d,
+	})
+}
+
+// FUNC TO LIST DISPUTES THE LOGGED-IN USER OPENED, IS PARTY TO OR ADMINISTERS
+func GetDisputesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	page, limit := utils.GetPaginationParams(r)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	query := `SELECT ` + disputeColumns + `
+		FROM disputes d
+		WHERE (d.opened_by = ? OR d.counterparty_id = ? OR EXISTS(
+			SELECT 1 FROM group_members gm WHERE gm.group_id = d.group_id AND gm.user_id = ? AND gm.role = 'admin'
+		))`
+	args := []interface{}{userID, userID, userID}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		valid := map[string]bool{"open": true, "responded": true, "resolved": true, "rejected": true}
+		if !valid[status] {
+			utils.WriteError(w, "invalid status filter", http.StatusBadRequest)
+			return
+		}
+		query += " AND d.status = ?"
+		args = append(args, status)
+	}
+
+	query += " ORDER BY d.created_at DESC, d.id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, (page-1)*limit)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.Logger.Errorf("failed to retrieve disputes: %v", err)
+		utils.WriteError(w, "failed to retrieve disputes", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	disputes := make([]models.Dispute, 0)
+	for rows.Next() {
+		var d models.Dispute
+		if err := scanDispute(rows, &d); err != nil {
+			utils.Logger.Errorf("error scanning dispute: %v", err)
+			utils.WriteError(w, "error reading disputes", http.StatusInternalServerError)
+			return
+		}
+		disputes = append(disputes, d)
+	}
+	if err := rows.Err(); err != nil {
+		utils.Logger.Errorf("error iterating disputes: %v", err)
+		utils.WriteError(w, "error reading disputes", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(disputes),
+		"page":   page,
+		"limit":  limit,
+		"data":   disputes,
+	})
+}
+
+// FUNC TO GET ONE DISPUTE
+func GetDisputeByIdHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	disputeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := loadDispute(ctx, db, disputeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "dispute not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if d.OpenedBy != userID && int(d.CounterpartyID.Int64) != userID {
+		isAdmin, err := isDisputeAdmin(ctx, db, d, userID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !isAdmin {
+			utils.WriteError(w, "you are not a party to this dispute", http.StatusForbidden)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data":   d,
+	})
+}
+
+// FUNC FOR THE COUNTERPARTY TO RESPOND TO A DISPUTE
+func RespondToDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	disputeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Response string `json:"response"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req.Response = strings.TrimSpace(req.Response)
+	if req.Response == "" {
+		utils.WriteError(w, "response is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := loadDispute(ctx, db, disputeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "dispute not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if int(d.CounterpartyID.Int64) != userID {
+		utils.WriteError(w, "only the other party can respond to this dispute", http.StatusForbidden)
+		return
+	}
+
+	if d.Status != "open" {
+		utils.WriteError(w, "dispute is not awaiting a response", http.StatusConflict)
+		return
+	}
+
+	_, err = db.ExecContext(ctx, "UPDATE disputes SET status = 'responded', response = ?, responded_at = ? WHERE id = ? AND status = 'open'",
+		req.Response, time.Now().Format("2006-01-02 15:04:05"), disputeID)
+	if err != nil {
+		utils.Logger.Errorf("failed to respond to dispute: %v", err)
+		utils.WriteError(w, "failed to respond to dispute", http.StatusInternalServerError)
+		return
+	}
+
+	notifyDispute(ctx, db, d, userID, []int{d.OpenedBy}, "Dispute Response Received",
+		fmt.Sprintf("The other party has responded to your dispute on %s #%d.<br><br>Response: %s", d.SubjectType, d.SubjectID, html.EscapeString(req.Response)))
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "response recorded",
+	})
+}
+
+// FUNC TO RESOLVE A DISPUTE, OPTIONALLY REVERSING THE DISPUTED SPLIT PAYMENT
+func ResolveDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	closeDispute(w, r, "resolved")
+}
+
+// FUNC TO REJECT A DISPUTE
+func RejectDisputeHandler(w http.ResponseWriter, r *http.Request) {
+	closeDispute(w, r, "rejected")
+}
+
+func closeDispute(w http.ResponseWriter, r *http.Request, status string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	disputeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid dispute ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Note      string `json:"note"`
+		Reverse   bool   `json:"reverse"`
+		PaymentID int    `json:"payment_id"`
+		Pin       string `json:"pin"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Reverse && status != "resolved" {
+		utils.WriteError(w, "only a resolved dispute can be reversed", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	d, err := loadDispute(ctx, db, disputeID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "dispute not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if d.OpenedBy == userID {
+		utils.WriteError(w, "you cannot decide a dispute you opened", http.StatusForbidden)
+		return
+	}
+	// the party a group dispute is raised against cannot judge it; an uninvolved admin does
+	if d.GroupID.Valid {
+		if int(d.CounterpartyID.Int64) == userID {
+			utils.WriteError(w, "a group admin who is not a party must decide this dispute", http.StatusForbidden)
+			return
+		}
+		isAdmin, err := isDisputeAdmin(ctx, db, d, userID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !isAdmin {
+			utils.WriteError(w, "only a group admin can decide this dispute", http.StatusForbidden)
+			return
+		}
+	} else if int(d.CounterpartyID.Int64) != userID {
+		utils.WriteError(w, "only the other party can decide this dispute", http.StatusForbidden)
+		return
+	}
+
+	if !isOpen(d.Status) {
+		utils.WriteError(w, "dispute has already been closed", http.StatusConflict)
+		return
+	}
+
+	// a reversal refunds a split payment, so it must be one made towards the disputed split
+	paymentID := req.PaymentID
+	var payeeID int
+	if req.Reverse {
+		if paymentID == 0 && d.SubjectType == "transaction" {
+			err := db.QueryRowContext(ctx, `
+				SELECT id FROM split_payments WHERE ? IN (debit_transaction_id, credit_transaction_id)
+			`, d.SubjectID).Scan(&paymentID)
+			if err != nil && err != sql.ErrNoRows {
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+		}
+		if paymentID == 0 {
+			utils.WriteError(w, "payment_id is required to reverse this dispute", http.StatusBadRequest)
+			return
+		}
+
+		var paymentSplitID int64
+		err := db.QueryRowContext(ctx, "SELECT split_id, payee_id FROM split_payments WHERE id = ?", paymentID).Scan(&paymentSplitID, &payeeID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				utils.WriteError(w, "split payment not found", http.StatusNotFound)
+				return
+			}
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !d.SplitID.Valid || paymentSplitID != d.SplitID.Int64 {
+			utils.WriteError(w, "payment does not belong to the disputed split", http.StatusBadRequest)
+			return
+		}
+
+		// the money comes back out of the payee's wallet, so only they or an admin may send it
+		if payeeID != userID {
+			isAdmin, err := isDisputeAdmin(ctx, db, d, userID)
+			if err != nil {
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			if !isAdmin {
+				utils.WriteError(w, "only the payee or a group admin can reverse this payment", http.StatusForbidden)
+				return
+			}
+		}
+
+		if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+			handlers.WritePinError(w, err)
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Error("error starting transaction")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var refund *services.SplitRefund
+	var reversal sql.NullInt64
+	if req.Reverse {
+		refund, err = services.RefundSplitPayment(ctx, tx, paymentID, payeeID)
+		if err != nil {
+			tx.Rollback()
+			utils.Logger.Errorf("failed to reverse payment %d for dispute %d: %v", paymentID, disputeID, err)
+			handlers.WriteSettlementError(w, err)
+			return
+		}
+		reversal = sql.NullInt64{Int64: int64(paymentID), Valid: true}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE disputes SET status = ?, resolution_note = ?, resolved_by = ?, resolved_at = ?, reversal_payment_id = ?
+		WHERE id = ? AND status IN ('open', 'responded')
+	`, status, req.Note, userID, time.Now().Format("2006-01-02 15:04:05"), reversal, disputeID)
+	if err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to close dispute: %v", err)
+		utils.WriteError(w, "failed to update dispute", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		utils.WriteError(w, "dispute has already been closed", http.StatusConflict)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.Logger.Errorf("transaction commit failed: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if refund != nil {
+		services.NotifySplitRefund(ctx, db, refund)
+	}
+
+	message := fmt.Sprintf("Your dispute on %s #%d has been %s.", d.SubjectType, d.SubjectID, status)
+	if req.Note != "" {
+		message += "<br><br>Note: " + html.EscapeString(req.Note)
+	}
+	if refund != nil {
+		message += fmt.Sprintf("<br><br>₦%s has been refunded to your wallet.", refund.Amount.StringFixed(2))
+	}
+	notifyDispute(ctx, db, d, userID, []int{d.OpenedBy, int(d.CounterpartyID.Int64)}, "Dispute "+strings.ToUpper(status[:1])+status[1:], message)
+
+	data := map[string]interface{}{
+		"dispute_id": disputeID,
+		"status":     status,
+	}
+	if refund != nil {
+		data["reversed_payment_id"] = refund.PaymentID
+		data["amount_refunded"] = refund.Amount
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "dispute " + status,
+		"data":    data,
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+)
+
+// rowQueryer is satisfied by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// IsGroupAdmin reports whether the user holds the admin role in the group
+func IsGroupAdmin(ctx context.Context, q rowQueryer, groupID, userID int) (bool, error) {
+	var isAdmin bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ? AND role = 'admin')
+	`, groupID, userID).Scan(&isAdmin)
+	return isAdmin, err
+}
+
+// GroupAdminIDs lists the admins of a group
+func GroupAdminIDs(ctx context.Context, db *sql.DB, groupID int) ([]int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND role = 'admin'", groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
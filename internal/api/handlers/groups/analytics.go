@@ -0,0 +1,319 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// syncGroupTotal recomputes groups.total_expense from the group's expenses
+func syncGroupTotal(ctx context.Context, tx *sql.Tx, groupID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE groups SET total_expense = (SELECT COALESCE(SUM(amount), 0) FROM group_expenses WHERE group_id = ?)
+		WHERE id = ?
+	`, groupID, groupID)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to update group total")
+	}
+	return nil
+}
+
+type periodTotals struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Count   int             `json:"count"`
+	Total   decimal.Decimal `json:"total"`
+	Average decimal.Decimal `json:"average"`
+}
+
+type memberSpending struct {
+	UserID   int             `json:"user_id"`
+	Username string          `json:"username"`
+	Paid     decimal.Decimal `json:"paid"`
+	Share    decimal.Decimal `json:"share"`
+	Net      decimal.Decimal `json:"net"`
+}
+
+type payerSpending struct {
+	UserID   int             `json:"user_id"`
+	Username string          `json:"username"`
+	Count    int             `json:"count"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+type categorySpending struct {
+	CategoryID sql.NullInt64   `json:"category_id"`
+	Category   string          `json:"category"`
+	Count      int             `json:"count"`
+	Total      decimal.Decimal `json:"total"`
+}
+
+type monthSpending struct {
+	Month string          `json:"month"`
+	Count int             `json:"count"`
+	Total decimal.Decimal `json:"total"`
+}
+
+// percentChange returns nil when there is no previous value to compare against
+func percentChange(current, previous decimal.Decimal) *decimal.Decimal {
+	if previous.IsZero() {
+		return nil
+	}
+	change := current.Sub(previous).Div(previous).Mul(decimal.NewFromInt(100)).Round(2)
+	return &change
+}
+
+func fetchPeriodTotals(ctx context.Context, db *sql.DB, groupID int, from, to time.Time) (periodTotals, error) {
+	totals := periodTotals{From: from.Format("2006-01-02"), To: to.AddDate(0, 0, -1).Format("2006-01-02")}
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(amount), 0), COALESCE(ROUND(AVG(amount), 2), 0)
+		FROM group_expenses
+		WHERE group_id = ? AND created_at >= ? AND created_at < ?
+	`, groupID, from.Format("2006-01-02"), to.Format("2006-01-02")).Scan(&totals.Count, &totals.Total, &totals.Average)
+	return totals, err
+}
+
+// FUNC TO GET SPENDING ANALYTICS FOR A GROUP
+func GetGroupAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	// the period is [from, to] inclusive; defaults to the last six months
+	to, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			utils.WriteError(w, "to must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, -6, 0)
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err = time.Parse("2006-01-02", v)
+		if err != nil {
+			utils.WriteError(w, "from must be a date in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		utils.WriteError(w, "from must not be after to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		utils.WriteError(w, "period cannot be longer than one year", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var groupName string
+	var totalExpense decimal.Decimal
+	err = db.QueryRowContext(ctx, "SELECT name, total_expense FROM groups WHERE id = ?", groupID).Scan(&groupName, &totalExpense)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "failed to fetch group", http.StatusInternalServerError)
+		return
+	}
+
+	var isMember bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?)", groupID, userID).Scan(&isMember)
+	if err != nil {
+		utils.WriteError(w, "failed to verify group membership", http.StatusInternalServerError)
+		return
+	}
+	if !isMember {
+		utils.WriteError(w, "you are not a member of this group", http.StatusForbidden)
+		return
+	}
+
+	fromStr, toStr := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	current, err := fetchPeriodTotals(ctx, db, groupID, from, to)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch period totals: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+
+	previous, err := fetchPeriodTotals(ctx, db, groupID, from.Add(-to.Sub(from)), from)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch previous period totals: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+
+	byMonth := make([]monthSpending, 0)
+	rows, err := db.QueryContext(ctx, `
+		SELECT DATE_FORMAT(created_at, '%Y-%m') AS month, COUNT(*), SUM(amount)
+		FROM group_expenses
+		WHERE group_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY month
+		ORDER BY month
+	`, groupID, fromStr, toStr)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch monthly spending: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m monthSpending
+		if err := rows.Scan(&m.Month, &m.Count, &m.Total); err != nil {
+			utils.WriteError(w, "error reading group analytics", http.StatusInternalServerError)
+			return
+		}
+		byMonth = append(byMonth, m)
+	}
+
+	byPayer := make([]payerSpending, 0)
+	payerRows, err := db.QueryContext(ctx, `
+		SELECT e.paid_by, u.username, COUNT(*), SUM(e.amount) AS total
+		FROM group_expenses e
+		JOIN users u ON u.id = e.paid_by
+		WHERE e.group_id = ? AND e.created_at >= ? AND e.created_at < ?
+		GROUP BY e.paid_by, u.username
+		ORDER BY total DESC
+	`, groupID, fromStr, toStr)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch spending by payer: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+	defer payerRows.Close()
+
+	for payerRows.Next() {
+		var p payerSpending
+		if err := payerRows.Scan(&p.UserID, &p.Username, &p.Count, &p.Total); err != nil {
+			utils.WriteError(w, "error reading group analytics", http.StatusInternalServerError)
+			return
+		}
+		byPayer = append(byPayer, p)
+	}
+
+	byCategory := make([]categorySpending, 0)
+	categoryRows, err := db.QueryContext(ctx, `
+		SELECT e.category_id, COALESCE(c.name, 'Uncategorized'), COUNT(*), SUM(e.amount) AS total
+		FROM group_expenses e
+		LEFT JOIN expense_categories c ON c.id = e.category_id
+		WHERE e.group_id = ? AND e.created_at >= ? AND e.created_at < ?
+		GROUP BY e.category_id, c.name
+		ORDER BY total DESC
+	`, groupID, fromStr, toStr)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch spending by category: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+	defer categoryRows.Close()
+
+	for categoryRows.Next() {
+		var c categorySpending
+		if err := categoryRows.Scan(&c.CategoryID, &c.Category, &c.Count, &c.Total); err != nil {
+			utils.WriteError(w, "error reading group analytics", http.StatusInternalServerError)
+			return
+		}
+		byCategory = append(byCategory, c)
+	}
+
+	// each member's share is their split plus, for expenses they paid, whatever
+	// was not split out to others
+	memberRows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.username,
+			COALESCE((
+				SELECT SUM(e.amount) FROM group_expenses e
+				WHERE e.group_id = ? AND e.paid_by = u.id AND e.created_at >= ? AND e.created_at < ?
+			), 0) AS paid,
+			COALESCE((
+				SELECT SUM(s.share_amount) FROM group_expense_splits s
+				JOIN group_expenses e ON e.id = s.expense_id
+				WHERE e.group_id = ? AND s.owed_by = u.id AND e.created_at >= ? AND e.created_at < ?
+			), 0) + COALESCE((
+				SELECT SUM(e.amount - (SELECT COALESCE(SUM(s.share_amount), 0) FROM group_expense_splits s WHERE s.expense_id = e.id))
+				FROM group_expenses e
+				WHERE e.group_id = ? AND e.paid_by = u.id AND e.created_at >= ? AND e.created_at < ?
+			), 0) AS share
+		FROM users u
+		WHERE u.id IN (
+			SELECT user_id FROM group_members WHERE group_id = ?
+			UNION
+			SELECT paid_by FROM group_expenses WHERE group_id = ? AND created_at >= ? AND created_at < ?
+		)
+		ORDER BY paid DESC, u.username
+	`, groupID, fromStr, toStr, groupID, fromStr, toStr, groupID, fromStr, toStr, groupID, groupID, fromStr, toStr)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch spending by member: %v", err)
+		utils.WriteError(w, "failed to fetch group analytics", http.StatusInternalServerError)
+		return
+	}
+	defer memberRows.Close()
+
+	byMember := make([]memberSpending, 0)
+	for memberRows.Next() {
+		var m memberSpending
+		if err := memberRows.Scan(&m.UserID, &m.Username, &m.Paid, &m.Share); err != nil {
+			utils.WriteError(w, "error reading group analytics", http.StatusInternalServerError)
+			return
+		}
+		m.Net = m.Paid.Sub(m.Share)
+		byMember = append(byMember, m)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"group": map[string]interface{}{
+				"id":            groupID,
+				"name":          groupName,
+				"total_expense": totalExpense,
+			},
+			"period":          current,
+			"previous_period": previous,
+			"trend": map[string]interface{}{
+				"total_change":           current.Total.Sub(previous.Total),
+				"total_change_percent":   percentChange(current.Total, previous.Total),
+				"count_change":           current.Count - previous.Count,
+				"average_change":         current.Average.Sub(previous.Average),
+				"average_change_percent": percentChange(current.Average, previous.Average),
+			},
+			"by_member":   byMember,
+			"by_payer":    byPayer,
+			"by_month":    byMonth,
+			"by_category": byCategory,
+		},
+	})
+}
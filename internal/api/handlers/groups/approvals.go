@@ -0,0 +1,285 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// requiresApproval reports whether an expense of amount added by userID must
+// wait for an admin. Admins never need approval.
+func requiresApproval(ctx context.Context, db *sql.DB, groupID, userID int, amount decimal.Decimal) (bool, error) {
+	settings, err := loadGroupSettings(ctx, db, groupID)
+	if err != nil {
+		return false, err
+	}
+	if !settings.ApprovalThreshold.Valid || amount.LessThanOrEqual(settings.ApprovalThreshold.Decimal) {
+		return false, nil
+	}
+
+	role, err := memberRole(ctx, db, groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return role != "admin", nil
+}
+
+// submitForApproval queues an expense for an admin to approve and writes the response
+func submitForApproval(ctx context.Context, w http.ResponseWriter, db *sql.DB, groupID, userID int, description string, categoryID sql.NullInt64, amount decimal.Decimal) {
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO expense_approvals (group_id, paid_by, description, category_id, amount) VALUES (?, ?, ?, ?, ?)
+	`, groupID, userID, description, categoryID, amount)
+	if err != nil {
+		utils.Logger.Errorf("failed to queue expense for approval: %v", err)
+		utils.WriteError(w, "failed to create expense", http.StatusInternalServerError)
+		return
+	}
+	approvalID, _ := res.LastInsertId()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "expense is above the group's approval threshold and is waiting for an admin",
+		"data": models.ExpenseApproval{
+			ID:          int(approvalID),
+			GroupID:     groupID,
+			PaidBy:      userID,
+			Description: description,
+			CategoryID:  categoryID,
+			Amount:      amount,
+			Status:      "pending",
+		},
+	})
+}
+
+// FUNC TO LIST EXPENSES WAITING FOR ADMIN APPROVAL IN A GROUP
+func GetPendingExpensesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	// admins see the whole queue, everyone else only what they submitted
+	query := `
+		SELECT id, group_id, paid_by, description, category_id, amount, status, created_at
+		FROM expense_approvals
+		WHERE group_id = ? AND status = 'pending'`
+	args := []interface{}{groupID}
+	role, err := memberRole(ctx, db, groupID, userID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if role != "admin" {
+		query += " AND paid_by = ?"
+		args = append(args, userID)
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve pending expenses", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	pending := make([]models.ExpenseApproval, 0)
+	for rows.Next() {
+		var a models.ExpenseApproval
+		if err := rows.Scan(&a.ID, &a.GroupID, &a.PaidBy, &a.Description, &a.CategoryID, &a.Amount, &a.Status, &a.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading pending expenses", http.StatusInternalServerError)
+			return
+		}
+		pending = append(pending, a)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(pending),
+		"data":   pending,
+	})
+}
+
+// FUNC TO APPROVE A PENDING EXPENSE AND SPLIT IT
+func ApproveExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	decideExpense(w, r, "approved")
+}
+
+// FUNC TO REJECT A PENDING EXPENSE
+func RejectExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	decideExpense(w, r, "rejected")
+}
+
+func decideExpense(w http.ResponseWriter, r *http.Request, decision string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	approvalID, err := strconv.Atoi(r.PathValue("approval_id"))
+	if err != nil {
+		utils.WriteError(w, "invalid approval ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Note string `json:"note"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil && err != io.EOF {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if len(req.Note) > 255 {
+		utils.WriteError(w, "note must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var a models.ExpenseApproval
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, group_id, paid_by, description, category_id, amount, status
+		FROM expense_approvals WHERE id = ? FOR UPDATE
+	`, approvalID).Scan(&a.ID, &a.GroupID, &a.PaidBy, &a.Description, &a.CategoryID, &a.Amount, &a.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "pending expense not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, tx, a.GroupID, userID, actionManageGroup) {
+		return
+	}
+
+	if a.Status != "pending" {
+		utils.WriteError(w, "expense has already been "+a.Status, http.StatusConflict)
+		return
+	}
+
+	var recorded *recordedExpense
+	if decision == "approved" {
+		archived, err := groupIsArchived(ctx, tx, a.GroupID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if archived {
+			utils.WriteError(w, "group is archived", http.StatusConflict)
+			return
+		}
+
+		// the expense is split between whoever is a member at approval time
+		recorded, err = recordExpense(ctx, tx, a.GroupID, a.PaidBy, a.Description, a.CategoryID, a.Amount)
+		if err != nil {
+			if err == errNoMembersToSplit {
+				utils.WriteError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			utils.Logger.Errorf("failed to record approved expense: %v", err)
+			utils.WriteError(w, "failed to approve expense", http.StatusInternalServerError)
+			return
+		}
+		a.ExpenseID = sql.NullInt64{Int64: recorded.ExpenseID, Valid: true}
+	}
+
+	note := sql.NullString{String: req.Note, Valid: req.Note != ""}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE expense_approvals SET status = ?, expense_id = ?, decided_by = ?, decision_note = ?, decided_at = ?
+		WHERE id = ?
+	`, decision, a.ExpenseID, userID, note, time.Now().Format("2006-01-02 15:04:05"), approvalID)
+	if err != nil {
+		utils.Logger.Errorf("failed to update expense approval: %v", err)
+		utils.WriteError(w, "failed to update pending expense", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to update pending expense", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"approval_id": approvalID,
+		"status":      decision,
+	}
+	if recorded != nil {
+		checkBudgetAlerts(ctx, db, a.GroupID, a.CategoryID)
+		data["expense_id"] = recorded.ExpenseID
+		data["split_each"] = recorded.Share
+		data["member_shares"] = recorded.MemberShares
+		data["credits_applied"] = recorded.CreditsApplied
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "expense " + decision,
+		"data":    data,
+	})
+}
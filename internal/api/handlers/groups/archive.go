@@ -0,0 +1,679 @@
+package groups
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// a purge can be confirmed once the delay has passed and for this long after
+const purgeConfirmWindow = 7 * 24 * time.Hour
+
+// rowQueryer is satisfied by both *sql.DB and *sql.Tx
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// groupIsArchived reports whether the group has been archived
+func groupIsArchived(ctx context.Context, q rowQueryer, groupID int) (bool, error) {
+	var archivedAt sql.NullString
+	err := q.QueryRowContext(ctx, "SELECT archived_at FROM groups WHERE id = ?", groupID).Scan(&archivedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+	return archivedAt.Valid, nil
+}
+
+// loadGroupForAdmin fetches the group and checks the user is its admin,
+// writing the error response itself when either fails
+func loadGroupForAdmin(ctx context.Context, w http.ResponseWriter, db *sql.DB, groupID, userID int) (*models.Group, bool) {
+	group := models.Group{ID: groupID}
+	err := db.QueryRowContext(ctx, "SELECT name, created_by, archived_at FROM groups WHERE id = ?", groupID).
+		Scan(&group.Name, &group.CreatedBy, &group.ArchivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return nil, false
+		}
+		utils.WriteError(w, "failed to retrieve group", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return nil, false
+	}
+
+	return &group, true
+}
+
+// purgeDelay is how long an admin must wait between requesting and confirming a purge
+func purgeDelay() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("GROUP_PURGE_DELAY_HOURS"))
+	if err != nil || hours < 0 {
+		hours = 72
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// FUNC TO RESTORE AN ARCHIVED GROUP
+func RestoreGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	group, ok := loadGroupForAdmin(ctx, w, db, groupID, userID)
+	if !ok {
+		return
+	}
+
+	if !group.ArchivedAt.Valid {
+		utils.WriteError(w, "group is not archived", http.StatusConflict)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "UPDATE groups SET archived_at = NULL, archived_by = NULL WHERE id = ?", groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to restore group: %v", err)
+		utils.WriteError(w, "failed to restore group", http.StatusInternalServerError)
+		return
+	}
+
+	// restoring a group calls off any purge waiting on it
+	_, err = tx.ExecContext(ctx, "UPDATE group_purge_requests SET status = 'cancelled' WHERE group_id = ? AND status = 'pending'", groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to cancel purge requests: %v", err)
+		utils.WriteError(w, "failed to restore group", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to restore group", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "group restored",
+	})
+}
+
+// FUNC TO EXPORT THE FULL HISTORY OF A GROUP AS JSON
+func ExportGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	var group models.Group
+	err = db.QueryRowContext(ctx, `
+		SELECT id, name, description, created_by, total_expense, created_at, updated_at, archived_at
+		FROM groups WHERE id = ?
+	`, groupID).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedBy, &group.TotalExpense,
+		&group.CreatedAt, &group.UpdatedAt, &group.ArchivedAt)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve group", http.StatusInternalServerError)
+		return
+	}
+
+	type exportMember struct {
+		UserID   int            `json:"user_id"`
+		Username string         `json:"username"`
+		Role     string         `json:"role"`
+		JoinedAt sql.NullString `json:"joined_at"`
+	}
+
+	memberRows, err := db.QueryContext(ctx, `
+		SELECT gm.user_id, u.username, gm.role, gm.joined_at
+		FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = ?
+		ORDER BY gm.joined_at
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve members", http.StatusInternalServerError)
+		return
+	}
+	defer memberRows.Close()
+
+	members := make([]exportMember, 0)
+	for memberRows.Next() {
+		var m exportMember
+		if err := memberRows.Scan(&m.UserID, &m.Username, &m.Role, &m.JoinedAt); err != nil {
+			utils.WriteError(w, "error reading members", http.StatusInternalServerError)
+			return
+		}
+		members = append(members, m)
+	}
+
+	type exportExpense struct {
+		models.GroupExpense
+		Splits []models.GroupExpenseSplit `json:"splits"`
+	}
+
+	expenseRows, err := db.QueryContext(ctx, `
+		SELECT id, group_id, paid_by, description, category_id, amount, created_at
+		FROM group_expenses WHERE group_id = ?
+		ORDER BY created_at, id
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve expenses", http.StatusInternalServerError)
+		return
+	}
+	defer expenseRows.Close()
+
+	expenses := make([]*exportExpense, 0)
+	byID := make(map[int]*exportExpense)
+	for expenseRows.Next() {
+		e := &exportExpense{Splits: make([]models.GroupExpenseSplit, 0)}
+		if err := expenseRows.Scan(&e.ID, &e.GroupID, &e.PaidBy, &e.Description, &e.CategoryID, &e.Amount, &e.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading expenses", http.StatusInternalServerError)
+			return
+		}
+		expenses = append(expenses, e)
+		byID[e.ID] = e
+	}
+
+	splitRows, err := db.QueryContext(ctx, `
+		SELECT s.id, s.expense_id, COALESCE(s.owed_by, 0), COALESCE(s.guest_id, 0), s.share_amount, s.amount_owed, s.is_settled, s.created_at
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = ?
+		ORDER BY s.id
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve splits", http.StatusInternalServerError)
+		return
+	}
+	defer splitRows.Close()
+
+	for splitRows.Next() {
+		var s models.GroupExpenseSplit
+		if err := splitRows.Scan(&s.ID, &s.ExpenseID, &s.OwedBy, &s.GuestID, &s.ShareAmount, &s.AmountOwed, &s.IsSettled, &s.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading splits", http.StatusInternalServerError)
+			return
+		}
+		if e, ok := byID[s.ExpenseID]; ok {
+			e.Splits = append(e.Splits, s)
+		}
+	}
+
+	paymentRows, err := db.QueryContext(ctx, `
+		SELECT p.id, p.split_id, p.payer_id, p.payee_id, p.amount, p.status, p.refunded_at, p.created_at
+		FROM split_payments p
+		JOIN group_expense_splits s ON s.id = p.split_id
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = ?
+		ORDER BY p.created_at, p.id
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve payments", http.StatusInternalServerError)
+		return
+	}
+	defer paymentRows.Close()
+
+	payments := make([]models.SplitPayment, 0)
+	for paymentRows.Next() {
+		var p models.SplitPayment
+		if err := paymentRows.Scan(&p.ID, &p.SplitID, &p.PayerID, &p.PayeeID, &p.Amount, &p.Status, &p.RefundedAt, &p.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading payments", http.StatusInternalServerError)
+			return
+		}
+		payments = append(payments, p)
+	}
+
+	type exportWriteOff struct {
+		SplitID      int             `json:"split_id"`
+		DebtorID     sql.NullInt64   `json:"debtor_id"`
+		GuestID      sql.NullInt64   `json:"guest_id"`
+		CreditorID   int             `json:"creditor_id"`
+		Amount       decimal.Decimal `json:"amount"`
+		WrittenOffBy int             `json:"written_off_by"`
+		Reason       sql.NullString  `json:"reason"`
+		CreatedAt    sql.NullString  `json:"created_at"`
+	}
+
+	writeOffRows, err := db.QueryContext(ctx, `
+		SELECT o.split_id, o.debtor_id, o.guest_id, o.creditor_id, o.amount, o.written_off_by, o.reason, o.created_at
+		FROM split_write_offs o
+		JOIN group_expense_splits s ON s.id = o.split_id
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = ?
+		ORDER BY o.created_at, o.id
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve write-offs", http.StatusInternalServerError)
+		return
+	}
+	defer writeOffRows.Close()
+
+	writeOffs := make([]exportWriteOff, 0)
+	for writeOffRows.Next() {
+		var o exportWriteOff
+		if err := writeOffRows.Scan(&o.SplitID, &o.DebtorID, &o.GuestID, &o.CreditorID, &o.Amount, &o.WrittenOffBy, &o.Reason, &o.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading write-offs", http.StatusInternalServerError)
+			return
+		}
+		writeOffs = append(writeOffs, o)
+	}
+
+	type exportOffset struct {
+		SplitID    int             `json:"split_id"`
+		DebtorID   int             `json:"debtor_id"`
+		CreditorID int             `json:"creditor_id"`
+		Amount     decimal.Decimal `json:"amount"`
+		RecordedBy int             `json:"recorded_by"`
+		CreatedAt  sql.NullString  `json:"created_at"`
+	}
+
+	offsetRows, err := db.QueryContext(ctx, `
+		SELECT o.split_id, o.debtor_id, o.creditor_id, o.amount, o.recorded_by, o.created_at
+		FROM split_offsets o
+		JOIN group_expense_splits s ON s.id = o.split_id
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE e.group_id = ?
+		ORDER BY o.created_at, o.id
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve offsets", http.StatusInternalServerError)
+		return
+	}
+	defer offsetRows.Close()
+
+	offsets := make([]exportOffset, 0)
+	for offsetRows.Next() {
+		var o exportOffset
+		if err := offsetRows.Scan(&o.SplitID, &o.DebtorID, &o.CreditorID, &o.Amount, &o.RecordedBy, &o.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading offsets", http.StatusInternalServerError)
+			return
+		}
+		offsets = append(offsets, o)
+	}
+
+	filename := fmt.Sprintf("group_%d_export_%s.json", groupID, time.Now().Format("20060102"))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"exported_at": time.Now().Format(time.RFC3339),
+		"data": map[string]interface{}{
+			"group":      group,
+			"members":    members,
+			"expenses":   expenses,
+			"payments":   payments,
+			"write_offs": writeOffs,
+			"offsets":    offsets,
+		},
+	})
+}
+
+// FUNC TO REQUEST PERMANENT DELETION OF AN ARCHIVED GROUP
+func RequestGroupPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	group, ok := loadGroupForAdmin(ctx, w, db, groupID, userID)
+	if !ok {
+		return
+	}
+
+	if !group.ArchivedAt.Valid {
+		utils.WriteError(w, "only archived groups can be purged", http.StatusConflict)
+		return
+	}
+
+	var email, firstName string
+	err = db.QueryRowContext(ctx, "SELECT email, first_name FROM users WHERE id = ?", userID).Scan(&email, &firstName)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+	hashed := sha256.Sum256(tokenBytes)
+
+	purgeAfter := time.Now().Add(purgeDelay())
+	expiresAt := purgeAfter.Add(purgeConfirmWindow)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// a new request replaces any earlier one, so only the latest token works
+	_, err = tx.ExecContext(ctx, "UPDATE group_purge_requests SET status = 'cancelled' WHERE group_id = ? AND status = 'pending'", groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to request purge", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO group_purge_requests (group_id, requested_by, token_hash, purge_after, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, groupID, userID, hex.EncodeToString(hashed[:]), purgeAfter.Format("2006-01-02 15:04:05"), expiresAt.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		utils.Logger.Errorf("failed to create purge request: %v", err)
+		utils.WriteError(w, "failed to request purge", http.StatusInternalServerError)
+		return
+	}
+	requestID, _ := res.LastInsertId()
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to request purge", http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		if err := utils.SendGroupPurgeEmail(email, firstName, group.Name, token, purgeAfter, expiresAt); err != nil {
+			utils.Logger.Errorf("failed to send purge confirmation email: %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "purge requested; a confirmation token has been sent to your email",
+		"data": models.GroupPurgeRequest{
+			ID:          int(requestID),
+			GroupID:     groupID,
+			RequestedBy: userID,
+			PurgeAfter:  purgeAfter.Format(time.RFC3339),
+			ExpiresAt:   expiresAt.Format(time.RFC3339),
+			Status:      "pending",
+		},
+	})
+}
+
+// FUNC TO CONFIRM A PENDING PURGE AND PERMANENTLY DELETE THE GROUP
+func ConfirmGroupPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Token string `json:"token"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	tokenBytes, err := hex.DecodeString(req.Token)
+	if err != nil || len(tokenBytes) != 32 {
+		utils.WriteError(w, "invalid purge token", http.StatusBadRequest)
+		return
+	}
+	hashed := sha256.Sum256(tokenBytes)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	group, ok := loadGroupForAdmin(ctx, w, db, groupID, userID)
+	if !ok {
+		return
+	}
+
+	if !group.ArchivedAt.Valid {
+		utils.WriteError(w, "only archived groups can be purged", http.StatusConflict)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var purge models.GroupPurgeRequest
+	var ready, expired bool
+	// the times were written from the app's clock, so they are compared against it too
+	now := time.Now().Format("2006-01-02 15:04:05")
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, purge_after <= ?, expires_at <= ?
+		FROM group_purge_requests
+		WHERE group_id = ? AND token_hash = ? AND status = 'pending'
+		FOR UPDATE
+	`, now, now, groupID, hex.EncodeToString(hashed[:])).Scan(&purge.ID, &ready, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "purge token is invalid or has been cancelled", http.StatusBadRequest)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if expired {
+		utils.WriteError(w, "purge token has expired; request a new one", http.StatusGone)
+		return
+	}
+	if !ready {
+		utils.WriteError(w, "purge cannot be confirmed until the waiting period has passed", http.StatusConflict)
+		return
+	}
+
+	// history tables restrict group deletion, so they are cleared first; splits,
+	// payments, members, invitations, categories and budgets cascade from these.
+	// guests go after the expenses, whose splits and guest payments reference them
+	purgeSteps := []struct {
+		query string
+		what  string
+	}{
+		{`DELETE o FROM split_write_offs o
+			JOIN group_expense_splits s ON s.id = o.split_id
+			JOIN group_expenses e ON e.id = s.expense_id
+			WHERE e.group_id = ?`, "write-offs"},
+		{`DELETE o FROM split_offsets o
+			JOIN group_expense_splits s ON s.id = o.split_id
+			JOIN group_expenses e ON e.id = s.expense_id
+			WHERE e.group_id = ?`, "offsets"},
+		{"DELETE FROM member_credits WHERE group_id = ?", "member credits"},
+		{"DELETE FROM group_expenses WHERE group_id = ?", "expenses"},
+		{"DELETE FROM group_guests WHERE group_id = ?", "guests"},
+		{"DELETE FROM groups WHERE id = ?", "group"},
+	}
+	for _, step := range purgeSteps {
+		if _, err := tx.ExecContext(ctx, step.query, groupID); err != nil {
+			utils.Logger.Errorf("failed to purge %s of group %d: %v", step.what, groupID, err)
+			utils.WriteError(w, "failed to purge group", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_purge_requests SET status = 'completed', completed_at = NOW() WHERE id = ?", purge.ID)
+	if err != nil {
+		utils.WriteError(w, "failed to purge group", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to purge group", http.StatusInternalServerError)
+		return
+	}
+
+	utils.Logger.Infof("group %d permanently purged by user %d", groupID, userID)
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "group permanently deleted",
+	})
+}
+
+// FUNC TO CANCEL A PENDING PURGE WITHOUT RESTORING THE GROUP
+func CancelGroupPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	res, err := db.ExecContext(ctx, "UPDATE group_purge_requests SET status = 'cancelled' WHERE group_id = ? AND status = 'pending'", groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to cancel purge", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		utils.WriteError(w, "no pending purge for this group", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "purge cancelled",
+	})
+}
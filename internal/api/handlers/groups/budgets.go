@@ -0,0 +1,370 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// budgetThresholds are the percentages of a budget at which members are alerted
+var budgetThresholds = []int{80, 100}
+
+type budgetProgress struct {
+	ID         int             `json:"id"`
+	CategoryID sql.NullInt64   `json:"category_id"`
+	Category   string          `json:"category"`
+	Amount     decimal.Decimal `json:"amount"`
+	Spent      decimal.Decimal `json:"spent"`
+	Remaining  decimal.Decimal `json:"remaining"`
+	Percent    decimal.Decimal `json:"percent"`
+	Status     string          `json:"status"`
+}
+
+// fetchBudgetProgress reports spending against every budget of the group for
+// the month starting at start
+func fetchBudgetProgress(ctx context.Context, db *sql.DB, groupID int, start time.Time) ([]budgetProgress, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.category_id, COALESCE(c.name, 'Overall'), b.amount,
+			COALESCE((
+				SELECT SUM(e.amount) FROM group_expenses e
+				WHERE e.group_id = b.group_id AND (b.category_id IS NULL OR e.category_id = b.category_id)
+					AND e.created_at >= ? AND e.created_at < ?
+			), 0)
+		FROM group_budgets b
+		LEFT JOIN expense_categories c ON c.id = b.category_id
+		WHERE b.group_id = ?
+		ORDER BY b.category_id IS NOT NULL, c.name
+	`, start.Format("2006-01-02"), start.AddDate(0, 1, 0).Format("2006-01-02"), groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := make([]budgetProgress, 0)
+	for rows.Next() {
+		var b budgetProgress
+		if err := rows.Scan(&b.ID, &b.CategoryID, &b.Category, &b.Amount, &b.Spent); err != nil {
+			return nil, err
+		}
+
+		b.Remaining = decimal.Max(b.Amount.Sub(b.Spent), decimal.Zero)
+		b.Percent = b.Spent.Div(b.Amount).Mul(decimal.NewFromInt(100)).Round(2)
+		switch {
+		case b.Percent.GreaterThanOrEqual(decimal.NewFromInt(100)):
+			b.Status = "exceeded"
+		case b.Percent.GreaterThanOrEqual(decimal.NewFromInt(int64(budgetThresholds[0]))):
+			b.Status = "warning"
+		default:
+			b.Status = "ok"
+		}
+		budgets = append(budgets, b)
+	}
+
+	return budgets, rows.Err()
+}
+
+// checkBudgetAlerts emails group members when an expense pushes the overall
+// budget or its category's budget past a threshold for the current month.
+// Each threshold is only announced once per budget per month.
+func checkBudgetAlerts(ctx context.Context, db *sql.DB, groupID int, categoryID sql.NullInt64) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	period := start.Format("2006-01")
+
+	budgets, err := fetchBudgetProgress(ctx, db, groupID, start)
+	if err != nil {
+		utils.Logger.Errorf("failed to check budgets for group %d: %v", groupID, err)
+		return
+	}
+
+	for _, b := range budgets {
+		if b.CategoryID.Valid && (!categoryID.Valid || b.CategoryID.Int64 != categoryID.Int64) {
+			continue
+		}
+
+		crossed := 0
+		for _, threshold := range budgetThresholds {
+			if b.Percent.LessThan(decimal.NewFromInt(int64(threshold))) {
+				break
+			}
+			res, err := db.ExecContext(ctx, `
+				INSERT IGNORE INTO budget_alerts (budget_id, period, threshold, spent) VALUES (?, ?, ?, ?)
+			`, b.ID, period, threshold, b.Spent)
+			if err != nil {
+				utils.Logger.Errorf("failed to record budget alert for budget %d: %v", b.ID, err)
+				return
+			}
+			if affected, _ := res.RowsAffected(); affected > 0 {
+				crossed = threshold
+			}
+		}
+
+		if crossed > 0 {
+			notifyBudgetAlert(ctx, db, groupID, b, crossed, now.Format("January 2006"))
+		}
+	}
+}
+
+func notifyBudgetAlert(ctx context.Context, db *sql.DB, groupID int, b budgetProgress, threshold int, period string) {
+	var groupName string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM groups WHERE id = ?", groupID).Scan(&groupName); err != nil {
+		utils.Logger.Errorf("failed to load group %d for budget alert: %v", groupID, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.email, u.first_name FROM group_members gm
+		JOIN users u ON u.id = gm.user_id
+		WHERE gm.group_id = ?
+	`, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to load members for budget alert: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var email, firstName string
+		if err := rows.Scan(&email, &firstName); err != nil {
+			continue
+		}
+
+		go func(email, firstName string) {
+			err := utils.SendBudgetAlertEmail(email, firstName, groupName, b.Category, threshold, b.Spent.StringFixed(2), b.Amount.StringFixed(2), period)
+			if err != nil {
+				utils.Logger.Errorf("failed to send budget alert to %s: %v", email, err)
+			}
+		}(email, firstName)
+	}
+}
+
+// FUNC TO GET A GROUP'S BUDGETS AND HOW MUCH OF EACH HAS BEEN SPENT THIS MONTH
+func GetGroupBudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if v := r.URL.Query().Get("month"); v != "" {
+		if start, err = time.ParseInLocation("2006-01", v, now.Location()); err != nil {
+			utils.WriteError(w, "month must be in YYYY-MM format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	budgets, err := fetchBudgetProgress(ctx, db, groupID, start)
+	if err != nil {
+		utils.Logger.Errorf("failed to fetch budgets: %v", err)
+		utils.WriteError(w, "failed to retrieve budgets", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"month":  start.Format("2006-01"),
+		"count":  len(budgets),
+		"data":   budgets,
+	})
+}
+
+// FUNC TO SET THE MONTHLY BUDGET OF A GROUP, OVERALL OR FOR ONE CATEGORY
+func SetGroupBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		CategoryID *int            `json:"category_id"`
+		Amount     decimal.Decimal `json:"amount"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) || !utils.HasAtMostTwoDecimals(req.Amount) {
+		utils.WriteError(w, "amount must be greater than 0 with at most 2 decimal places", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	var categoryID sql.NullInt64
+	if req.CategoryID != nil {
+		valid, err := validCategory(ctx, db, groupID, *req.CategoryID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			utils.WriteError(w, "category not found in this group", http.StatusBadRequest)
+			return
+		}
+		categoryID = sql.NullInt64{Int64: int64(*req.CategoryID), Valid: true}
+	}
+
+	// the unique key on the group and category makes this an upsert; an update
+	// reports 2 affected rows, or 0 when the amount did not change
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO group_budgets (group_id, category_id, amount, created_by) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), amount = VALUES(amount)
+	`, groupID, categoryID, req.Amount, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to save budget: %v", err)
+		utils.WriteError(w, "failed to set budget", http.StatusInternalServerError)
+		return
+	}
+	id, _ := res.LastInsertId()
+	budgetID := int(id)
+
+	// alerts already sent this month were against the old limit
+	if affected, _ := res.RowsAffected(); affected == 2 {
+		_, err = db.ExecContext(ctx, "DELETE FROM budget_alerts WHERE budget_id = ? AND period = ?", budgetID, time.Now().Format("2006-01"))
+		if err != nil {
+			utils.Logger.Errorf("failed to reset budget alerts: %v", err)
+			utils.WriteError(w, "failed to set budget", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "budget saved",
+		"data": map[string]interface{}{
+			"id":          budgetID,
+			"group_id":    groupID,
+			"category_id": categoryID,
+			"amount":      req.Amount,
+		},
+	})
+}
+
+// FUNC TO REMOVE A GROUP BUDGET
+func DeleteGroupBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	budgetID, err := strconv.Atoi(r.PathValue("budgetId"))
+	if err != nil {
+		utils.WriteError(w, "invalid budget ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	res, err := db.ExecContext(ctx, "DELETE FROM group_budgets WHERE id = ? AND group_id = ?", budgetID, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to delete budget: %v", err)
+		utils.WriteError(w, "failed to delete budget", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		utils.WriteError(w, "budget not found", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "budget deleted",
+	})
+}
@@ -0,0 +1,266 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// loadGroupForMember fetches the group and checks the user belongs to it,
+// writing the error response itself when either fails
+func loadGroupForMember(ctx context.Context, w http.ResponseWriter, db *sql.DB, groupID, userID int) (*models.Group, bool) {
+	group := models.Group{ID: groupID}
+	err := db.QueryRowContext(ctx, "SELECT name, created_by FROM groups WHERE id = ?", groupID).Scan(&group.Name, &group.CreatedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return nil, false
+		}
+		utils.WriteError(w, "failed to retrieve group", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?)", groupID, userID).Scan(&exists)
+	if err != nil {
+		utils.WriteError(w, "failed to verify group membership", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !exists {
+		utils.WriteError(w, "you are not a member of this group", http.StatusForbidden)
+		return nil, false
+	}
+
+	return &group, true
+}
+
+// validCategory reports whether a category is a default or belongs to the group
+func validCategory(ctx context.Context, db *sql.DB, groupID, categoryID int) (bool, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM expense_categories WHERE id = ? AND (group_id IS NULL OR group_id = ?))
+	`, categoryID, groupID).Scan(&exists)
+	return exists, err
+}
+
+// FUNC TO LIST THE DEFAULT AND CUSTOM EXPENSE CATEGORIES OF A GROUP
+func GetGroupCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, group_id, name, created_by, created_at
+		FROM expense_categories
+		WHERE group_id IS NULL OR group_id = ?
+		ORDER BY group_id IS NOT NULL, name
+	`, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	categories := make([]models.ExpenseCategory, 0)
+	for rows.Next() {
+		var c models.ExpenseCategory
+		if err := rows.Scan(&c.ID, &c.GroupID, &c.Name, &c.CreatedBy, &c.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading categories", http.StatusInternalServerError)
+			return
+		}
+		categories = append(categories, c)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(categories),
+		"data":   categories,
+	})
+}
+
+// FUNC TO ADD A CUSTOM EXPENSE CATEGORY TO A GROUP
+func CreateGroupCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 50 {
+		utils.WriteError(w, "name is required and must be at most 50 characters", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	var exists bool
+	err = db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM expense_categories WHERE (group_id IS NULL OR group_id = ?) AND name = ?)
+	`, groupID, req.Name).Scan(&exists)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if exists {
+		utils.WriteError(w, "a category with this name already exists", http.StatusConflict)
+		return
+	}
+
+	res, err := db.ExecContext(ctx, "INSERT INTO expense_categories (group_id, name, created_by) VALUES (?, ?, ?)", groupID, req.Name, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to create category: %v", err)
+		utils.WriteError(w, "failed to create category", http.StatusInternalServerError)
+		return
+	}
+	categoryID, _ := res.LastInsertId()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "category created",
+		"data": models.ExpenseCategory{
+			ID:        int(categoryID),
+			GroupID:   sql.NullInt64{Int64: int64(groupID), Valid: true},
+			Name:      req.Name,
+			CreatedBy: sql.NullInt64{Int64: int64(userID), Valid: true},
+		},
+	})
+}
+
+// FUNC TO REMOVE A CUSTOM EXPENSE CATEGORY FROM A GROUP
+func DeleteGroupCategoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	categoryID, err := strconv.Atoi(r.PathValue("categoryId"))
+	if err != nil {
+		utils.WriteError(w, "invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	// expenses in the category become uncategorised and its budget goes with it
+	res, err := db.ExecContext(ctx, "DELETE FROM expense_categories WHERE id = ? AND group_id = ?", categoryID, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to delete category: %v", err)
+		utils.WriteError(w, "failed to delete category", http.StatusInternalServerError)
+		return
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		utils.WriteError(w, "category not found or is a default category", http.StatusNotFound)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "category deleted",
+	})
+}
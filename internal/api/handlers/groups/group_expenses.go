@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"qiyana_paybuddy/internal/api/handlers"
 	"qiyana_paybuddy/internal/models"
 	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
 	"qiyana_paybuddy/pkg/utils"
 	"reflect"
 	"strconv"
@@ -16,6 +19,126 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var errNoMembersToSplit = errors.New("no members to split expense with")
+
+// recordedExpense is the outcome of recordExpense. Share is what someone with a
+// split weight of 1 owes, MemberShares what each member other than the payer owes.
+type recordedExpense struct {
+	ExpenseID      int64
+	Members        int
+	Share          decimal.Decimal
+	MemberShares   map[int]decimal.Decimal
+	CreditsApplied decimal.Decimal
+}
+
+// splitShares divides amount between the payer, memberIDs and guestCount guests
+// by the group's default split weights. Guests and members without a weight
+// count once. It returns each member's share and the share of a weight of 1;
+// rounding differences stay with the payer.
+func splitShares(ctx context.Context, tx *sql.Tx, groupID, payerID int, memberIDs []int, guestCount int, amount decimal.Decimal) (map[int]decimal.Decimal, decimal.Decimal, error) {
+	weights, err := loadSplitWeights(ctx, tx, groupID)
+	if err != nil {
+		return nil, decimal.Zero, utils.ErrorHandler(err, "failed to fetch split weights")
+	}
+
+	byUser := make(map[int]decimal.Decimal, len(weights))
+	for _, sw := range weights {
+		byUser[sw.UserID] = sw.Weight
+	}
+	weightOf := func(userID int) decimal.Decimal {
+		if weight, ok := byUser[userID]; ok {
+			return weight
+		}
+		return decimal.NewFromInt(1)
+	}
+
+	total := weightOf(payerID).Add(decimal.NewFromInt(int64(guestCount)))
+	for _, memberID := range memberIDs {
+		total = total.Add(weightOf(memberID))
+	}
+
+	unit := amount.Div(total)
+	shares := make(map[int]decimal.Decimal, len(memberIDs))
+	for _, memberID := range memberIDs {
+		shares[memberID] = unit.Mul(weightOf(memberID)).Round(2)
+	}
+	return shares, unit.Round(2), nil
+}
+
+// recordExpense inserts an expense paid by payerID and splits it between the
+// payer, every other current member and every active guest of the group by the
+// group's split weights, offsetting each member's split with any credit they
+// hold with the payer
+func recordExpense(ctx context.Context, tx *sql.Tx, groupID, payerID int, description string, categoryID sql.NullInt64, amount decimal.Decimal) (*recordedExpense, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND user_id != ?", groupID, payerID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to fetch group members")
+	}
+
+	var memberIDs []int
+	for rows.Next() {
+		var memberID int
+		if err := rows.Scan(&memberID); err == nil {
+			memberIDs = append(memberIDs, memberID)
+		}
+	}
+	rows.Close()
+
+	guestIDs, err := activeGuestIDs(ctx, tx, groupID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to fetch group guests")
+	}
+
+	if len(memberIDs)+len(guestIDs) == 0 {
+		return nil, errNoMembersToSplit
+	}
+
+	recorded := &recordedExpense{Members: len(memberIDs) + len(guestIDs) + 1}
+	recorded.MemberShares, recorded.Share, err = splitShares(ctx, tx, groupID, payerID, memberIDs, len(guestIDs), amount)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO group_expenses (group_id, paid_by, description, category_id, amount, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		groupID, payerID, description, categoryID, amount, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to create expense")
+	}
+	recorded.ExpenseID, _ = res.LastInsertId()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO group_expense_splits (expense_id, owed_by, share_amount, amount_owed, is_settled) VALUES (?, ?, ?, ?, FALSE)`)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to prepare statement")
+	}
+	defer stmt.Close()
+
+	for _, memberID := range memberIDs {
+		share := recorded.MemberShares[memberID]
+		splitRes, err := stmt.ExecContext(ctx, recorded.ExpenseID, memberID, share, share)
+		if err != nil {
+			return nil, utils.ErrorHandler(err, "failed to split expense")
+		}
+
+		// credits left over from earlier overpayments between the pair offset the new split
+		splitID, _ := splitRes.LastInsertId()
+		applied, err := services.ApplyMemberCredits(ctx, tx, groupID, int(splitID), memberID, payerID)
+		if err != nil {
+			return nil, utils.ErrorHandler(err, "failed to apply member credits")
+		}
+		recorded.CreditsApplied = recorded.CreditsApplied.Add(applied)
+	}
+
+	if err := insertGuestSplits(ctx, tx, recorded.ExpenseID, guestIDs, recorded.Share); err != nil {
+		return nil, err
+	}
+
+	if err := syncGroupTotal(ctx, tx, groupID); err != nil {
+		return nil, err
+	}
+
+	return recorded, nil
+}
+
 // FUNC TO CREATE GROUP EXPENSES
 func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -40,6 +163,7 @@ func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 	type request struct {
 		GroupID     int             `json:"group_id"`
 		Description string          `json:"description"`
+		CategoryID  *int            `json:"category_id"`
 		Amount      decimal.Decimal `json:"amount"`
 	}
 
@@ -61,8 +185,8 @@ func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var group models.Group
-	err := db.QueryRowContext(ctx, "SELECT name, description, created_by FROM groups WHERE id = ?", req.GroupID).
-		Scan(&group.Name, &group.Description, &group.CreatedBy)
+	err := db.QueryRowContext(ctx, "SELECT name, description, created_by, archived_at FROM groups WHERE id = ?", req.GroupID).
+		Scan(&group.Name, &group.Description, &group.CreatedBy, &group.ArchivedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "group not found", http.StatusNotFound)
@@ -72,39 +196,45 @@ func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var exists bool
-	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?)", req.GroupID, userID).Scan(&exists)
-	if err != nil {
-		utils.WriteError(w, "failed to verify group membership", http.StatusInternalServerError)
+	if group.ArchivedAt.Valid {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
 		return
 	}
-	if !exists {
-		utils.WriteError(w, "you are not a member of this group", http.StatusForbidden)
+
+	if !authorizeGroupAction(ctx, w, db, req.GroupID, userID, actionAddExpense) {
 		return
 	}
 
-	rows, err := db.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND user_id != ?", req.GroupID, userID)
+	settings, err := loadGroupSettings(ctx, db, req.GroupID)
 	if err != nil {
-		utils.WriteError(w, "failed to fetch group members", http.StatusInternalServerError)
+		utils.WriteError(w, "failed to retrieve group settings", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
 
-	var memberIDs []int
-	for rows.Next() {
-		var memberID int
-		if err := rows.Scan(&memberID); err == nil {
-			memberIDs = append(memberIDs, memberID)
+	// expenses without a category fall back to the group's default
+	categoryID := settings.DefaultCategoryID
+	if req.CategoryID != nil {
+		valid, err := validCategory(ctx, db, req.GroupID, *req.CategoryID)
+		if err != nil {
+			utils.WriteError(w, "failed to verify category", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			utils.WriteError(w, "category not found in this group", http.StatusBadRequest)
+			return
 		}
+		categoryID = sql.NullInt64{Int64: int64(*req.CategoryID), Valid: true}
 	}
 
-	if len(memberIDs) == 0 {
-		utils.WriteError(w, "no members to split expense with", http.StatusBadRequest)
+	needsApproval, err := requiresApproval(ctx, db, req.GroupID, userID, req.Amount)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if needsApproval {
+		submitForApproval(ctx, w, db, req.GroupID, userID, req.Description, categoryID, req.Amount)
 		return
 	}
-
-	totalMembers := len(memberIDs) + 1
-	share := req.Amount.Div(decimal.NewFromInt(int64(totalMembers))).Round(2)
 
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -113,32 +243,16 @@ func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := tx.ExecContext(ctx, "INSERT INTO group_expenses (group_id, paid_by, description, amount, created_at) VALUES (?, ?, ?, ?, ?)",
-		req.GroupID, userID, req.Description, req.Amount, time.Now().Format("2006-01-02 15:04:05"))
+	recorded, err := recordExpense(ctx, tx, req.GroupID, userID, req.Description, categoryID, req.Amount)
 	if err != nil {
 		tx.Rollback()
-		utils.WriteError(w, "failed to create expense", http.StatusInternalServerError)
-		return
-	}
-
-	expenseID, _ := res.LastInsertId()
-
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO group_expense_splits (expense_id, owed_by, amount_owed, is_settled) VALUES (?, ?, ?, FALSE)`)
-	if err != nil {
-		tx.Rollback()
-		utils.Logger.Errorf("failed to prepare statement: %v", err)
-		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-	defer stmt.Close()
-
-	for _, memberID := range memberIDs {
-		if _, err := stmt.ExecContext(ctx, expenseID, memberID, share); err != nil {
-			tx.Rollback()
-			utils.Logger.Errorf("failed to split expense: %v", err)
-			utils.WriteError(w, "failed to split expense", http.StatusInternalServerError)
+		if err == errNoMembersToSplit {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		utils.Logger.Errorf("failed to record expense: %v", err)
+		utils.WriteError(w, "failed to create expense", http.StatusInternalServerError)
+		return
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -146,13 +260,19 @@ func CreateGroupExpenseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	checkBudgetAlerts(ctx, db, req.GroupID, categoryID)
+
 	response := map[string]interface{}{
 		"status":  "success",
-		"message": fmt.Sprintf("Expense created and split among %d members (including payer)", totalMembers),
+		"message": fmt.Sprintf("Expense created and split among %d members (including payer)", recorded.Members),
 		"data": map[string]interface{}{
-			"expense_id": expenseID,
-			"amount":     req.Amount,
-			"split_each": share,
+			"expense_id":      recorded.ExpenseID,
+			"amount":          req.Amount,
+			"category_id":     categoryID,
+			"currency":        settings.Currency,
+			"split_each":      recorded.Share,
+			"member_shares":   recorded.MemberShares,
+			"credits_applied": recorded.CreditsApplied,
 		},
 	}
 
@@ -214,14 +334,21 @@ func GetGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	listQuery, err := expenseSpec.Parse(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	query := `
-		SELECT e.id, e.description, e.amount, u.username AS paid_by, e.created_at
+		SELECT e.id, e.description, e.category_id, c.name, e.amount, u.username AS paid_by, e.created_at
 		FROM group_expenses e
 		JOIN users u ON e.paid_by = u.id
-		WHERE e.group_id = ?
-		ORDER BY e.created_at DESC
-	`
-	rows, err := db.QueryContext(ctx, query, groupID)
+		LEFT JOIN expense_categories c ON c.id = e.category_id
+		WHERE e.group_id = ?` + listQuery.Where + listQuery.OrderBy()
+	args := append([]interface{}{groupID}, listQuery.Args...)
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		utils.WriteError(w, "failed to retrieve expenses", http.StatusInternalServerError)
 		return
@@ -231,6 +358,8 @@ func GetGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 	type Expense struct {
 		ID          int            `json:"id"`
 		Description string         `json:"description"`
+		CategoryID  sql.NullInt64  `json:"category_id"`
+		Category    sql.NullString `json:"category"`
 		Amount      float64        `json:"amount"`
 		PaidBy      string         `json:"paid_by"`
 		CreatedAt   sql.NullString `json:"created_at"`
@@ -240,7 +369,7 @@ func GetGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 
 	for rows.Next() {
 		var e Expense
-		err := rows.Scan(&e.ID, &e.Description, &e.Amount, &e.PaidBy, &e.CreatedAt)
+		err := rows.Scan(&e.ID, &e.Description, &e.CategoryID, &e.Category, &e.Amount, &e.PaidBy, &e.CreatedAt)
 		if err != nil {
 			utils.Logger.Errorf("error reading expenses: %v", err)
 			utils.WriteError(w, "error reading expenses", http.StatusInternalServerError)
@@ -298,8 +427,13 @@ func GetExpenseByIdHandler(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var expense models.GroupExpense
-	err = db.QueryRowContext(ctx, "SELECT group_id, paid_by, description, amount FROM group_expenses WHERE id = ?", expenseID).
-		Scan(&expense.GroupID, &expense.PaidBy, &expense.Description, &expense.Amount)
+	var categoryName sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT e.group_id, e.paid_by, e.description, e.category_id, c.name, e.amount
+		FROM group_expenses e
+		LEFT JOIN expense_categories c ON c.id = e.category_id
+		WHERE e.id = ?
+	`, expenseID).Scan(&expense.GroupID, &expense.PaidBy, &expense.Description, &expense.CategoryID, &categoryName, &expense.Amount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "expense not found", http.StatusNotFound)
@@ -335,15 +469,17 @@ func GetExpenseByIdHandler(w http.ResponseWriter, r *http.Request) {
 	type GroupExpenseSplit struct {
 		ID         int             `json:"id"`
 		OwedBy     int             `json:"owed_by"`
+		GuestID    int             `json:"guest_id,omitempty"`
 		Username   string          `json:"username"`
 		AmountOwed decimal.Decimal `json:"amount_owed"`
 		IsSettled  bool            `json:"is_settled"`
 	}
 
 	query := `
-		SELECT s.id, s.owed_by, u.username, s.amount_owed, s.is_settled
+		SELECT s.id, COALESCE(s.owed_by, 0), COALESCE(s.guest_id, 0), COALESCE(u.username, g.display_name), s.amount_owed, s.is_settled
 		FROM group_expense_splits s
-		JOIN users u ON s.owed_by = u.id
+		LEFT JOIN users u ON s.owed_by = u.id
+		LEFT JOIN group_guests g ON s.guest_id = g.id
 		WHERE s.expense_id = ?;
 	`
 	rows, err := db.QueryContext(ctx, query, expenseID)
@@ -357,7 +493,7 @@ func GetExpenseByIdHandler(w http.ResponseWriter, r *http.Request) {
 	var splits []GroupExpenseSplit
 	for rows.Next() {
 		var s GroupExpenseSplit
-		if err := rows.Scan(&s.ID, &s.OwedBy, &s.Username, &s.AmountOwed, &s.IsSettled); err != nil {
+		if err := rows.Scan(&s.ID, &s.OwedBy, &s.GuestID, &s.Username, &s.AmountOwed, &s.IsSettled); err != nil {
 			utils.Logger.Errorf("error scanning split: %v", err)
 			continue
 		}
@@ -369,6 +505,8 @@ func GetExpenseByIdHandler(w http.ResponseWriter, r *http.Request) {
 		"data": map[string]interface{}{
 			"expense": map[string]interface{}{
 				"description": expense.Description,
+				"category_id": expense.CategoryID,
+				"category":    categoryName,
 				"amount":      expense.Amount,
 				"paid_by":     expense.PaidBy,
 				"group_id":    expense.GroupID,
@@ -385,6 +523,34 @@ func GetExpenseByIdHandler(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, response)
 }
 
+// errExpenseHasPayments is returned when money has already moved against an
+// expense's splits, so they can no longer be re-split or deleted
+var errExpenseHasPayments = errors.New("expense has payments, credits, offsets or write-offs recorded against its splits and can no longer be changed or deleted")
+
+// expenseHasPayments locks the expense's splits and reports whether anything has
+// been paid, credited, offset or written off against them. Re-splitting or
+// deleting the expense would cascade those records away or fail on them.
+func expenseHasPayments(ctx context.Context, tx *sql.Tx, expenseID int) (bool, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM group_expense_splits WHERE expense_id = ? FOR UPDATE", expenseID)
+	if err != nil {
+		return false, err
+	}
+	rows.Close()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM group_expense_splits s WHERE s.expense_id = ? AND (
+			EXISTS(SELECT 1 FROM split_payments p WHERE p.split_id = s.id)
+			OR EXISTS(SELECT 1 FROM offline_split_payments o WHERE o.split_id = s.id)
+			OR EXISTS(SELECT 1 FROM guest_split_payments gp WHERE gp.split_id = s.id)
+			OR EXISTS(SELECT 1 FROM member_credit_applications a WHERE a.split_id = s.id)
+			OR EXISTS(SELECT 1 FROM split_write_offs wo WHERE wo.split_id = s.id)
+			OR EXISTS(SELECT 1 FROM split_offsets so WHERE so.split_id = s.id)
+		))
+	`, expenseID).Scan(&exists)
+	return exists, err
+}
+
 // FUNC TO UPDATE GROUP EXPENSES
 func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPatch {
@@ -424,8 +590,8 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var expense models.GroupExpense
-	err = db.QueryRowContext(ctx, "SELECT id, group_id, paid_by, description, amount FROM group_expenses WHERE id = ?", expenseID).
-		Scan(&expense.ID, &expense.GroupID, &expense.PaidBy, &expense.Description, &expense.Amount)
+	err = db.QueryRowContext(ctx, "SELECT id, group_id, paid_by, description, category_id, amount FROM group_expenses WHERE id = ?", expenseID).
+		Scan(&expense.ID, &expense.GroupID, &expense.PaidBy, &expense.Description, &expense.CategoryID, &expense.Amount)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "expense not found", http.StatusNotFound)
@@ -435,8 +601,8 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if expense.PaidBy != userID {
-		utils.WriteError(w, "you are not authorized to edit this expense entry", http.StatusUnauthorized)
+	// the payer can always edit their own expense; anyone else needs the group's edit policy
+	if expense.PaidBy != userID && !authorizeGroupAction(ctx, w, db, expense.GroupID, userID, actionEditOthersExpense) {
 		return
 	}
 
@@ -451,6 +617,16 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	archived, err := groupIsArchived(ctx, db, expense.GroupID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if archived {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
 	if amountVal, ok := request["amount"]; ok {
 		var newAmount decimal.Decimal
 		switch v := amountVal.(type) {
@@ -467,6 +643,39 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		request["amount"] = newAmount
+
+		needsApproval, err := requiresApproval(ctx, db, expense.GroupID, userID, newAmount)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if needsApproval && newAmount.GreaterThan(expense.Amount) {
+			utils.WriteError(w, "only an admin can raise an expense above the group's approval threshold", http.StatusForbidden)
+			return
+		}
+	}
+
+	// category_id is nullable, so it is handled here rather than by the reflection below
+	if v, ok := request["category_id"]; ok {
+		delete(request, "category_id")
+		switch id := v.(type) {
+		case nil:
+			expense.CategoryID = sql.NullInt64{}
+		case float64:
+			valid, err := validCategory(ctx, db, expense.GroupID, int(id))
+			if err != nil {
+				utils.WriteError(w, "failed to verify category", http.StatusInternalServerError)
+				return
+			}
+			if !valid || id != float64(int(id)) {
+				utils.WriteError(w, "category not found in this group", http.StatusBadRequest)
+				return
+			}
+			expense.CategoryID = sql.NullInt64{Int64: int64(id), Valid: true}
+		default:
+			utils.WriteError(w, "invalid category_id type", http.StatusBadRequest)
+			return
+		}
 	}
 
 	expenseVal := reflect.ValueOf(&expense).Elem()
@@ -494,16 +703,28 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	hasPayments, err := expenseHasPayments(ctx, tx, expense.ID)
+	if err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if hasPayments {
+		tx.Rollback()
+		utils.WriteError(w, errExpenseHasPayments.Error(), http.StatusConflict)
+		return
+	}
+
 	_, err = tx.ExecContext(ctx,
-		"UPDATE group_expenses SET description = ?, amount = ? WHERE id = ?",
-		expense.Description, expense.Amount, expense.ID)
+		"UPDATE group_expenses SET description = ?, category_id = ?, amount = ? WHERE id = ?",
+		expense.Description, expense.CategoryID, expense.Amount, expense.ID)
 	if err != nil {
 		tx.Rollback()
 		utils.WriteError(w, "error updating expense", http.StatusInternalServerError)
 		return
 	}
 
-	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND user_id != ?", expense.GroupID, userID)
+	rows, err := tx.QueryContext(ctx, "SELECT user_id FROM group_members WHERE group_id = ? AND user_id != ?", expense.GroupID, expense.PaidBy)
 	if err != nil {
 		tx.Rollback()
 		utils.WriteError(w, "failed to fetch group members", http.StatusInternalServerError)
@@ -519,14 +740,26 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	totalMembers := len(memberIDs) + 1
+	guestIDs, err := activeGuestIDs(ctx, tx, expense.GroupID)
+	if err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "failed to fetch group guests", http.StatusInternalServerError)
+		return
+	}
+
+	totalMembers := len(memberIDs) + len(guestIDs) + 1
 	if totalMembers == 0 {
 		tx.Rollback()
 		utils.WriteError(w, "no members found to split expense", http.StatusBadRequest)
 		return
 	}
 
-	share := expense.Amount.Div(decimal.NewFromInt(int64(totalMembers))).Round(2)
+	shares, share, err := splitShares(ctx, tx, expense.GroupID, expense.PaidBy, memberIDs, len(guestIDs), expense.Amount)
+	if err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "failed to split expense", http.StatusInternalServerError)
+		return
+	}
 
 	_, err = tx.ExecContext(ctx, "DELETE FROM group_expense_splits WHERE expense_id = ?", expense.ID)
 	if err != nil {
@@ -535,7 +768,7 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO group_expense_splits (expense_id, owed_by, amount_owed, is_settled) VALUES (?, ?, ?, FALSE)`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO group_expense_splits (expense_id, owed_by, share_amount, amount_owed, is_settled) VALUES (?, ?, ?, ?, FALSE)`)
 	if err != nil {
 		tx.Rollback()
 		utils.WriteError(w, "failed to prepare statement", http.StatusInternalServerError)
@@ -544,26 +777,42 @@ func UpdateGroupExpensesHandler(w http.ResponseWriter, r *http.Request) {
 	defer stmt.Close()
 
 	for _, memberID := range memberIDs {
-		if _, err := stmt.ExecContext(ctx, expense.ID, memberID, share); err != nil {
+		if _, err := stmt.ExecContext(ctx, expense.ID, memberID, shares[memberID], shares[memberID]); err != nil {
 			tx.Rollback()
 			utils.WriteError(w, "failed to recreate splits", http.StatusInternalServerError)
 			return
 		}
 	}
 
+	if err := insertGuestSplits(ctx, tx, int64(expense.ID), guestIDs, share); err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "failed to recreate splits", http.StatusInternalServerError)
+		return
+	}
+
+	if err := syncGroupTotal(ctx, tx, expense.GroupID); err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to update group total: %v", err)
+		utils.WriteError(w, "error updating expense", http.StatusInternalServerError)
+		return
+	}
+
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
 		utils.WriteError(w, "failed to commit transaction", http.StatusInternalServerError)
 		return
 	}
 
+	checkBudgetAlerts(ctx, db, expense.GroupID, expense.CategoryID)
+
 	response := map[string]interface{}{
 		"status":  "success",
 		"message": "Expense updated successfully",
 		"data": map[string]interface{}{
-			"expense_id": expense.ID,
-			"new_amount": expense.Amount,
-			"split_each": share,
+			"expense_id":    expense.ID,
+			"new_amount":    expense.Amount,
+			"split_each":    share,
+			"member_shares": shares,
 		},
 	}
 
@@ -594,20 +843,23 @@ func GetUserBalanceSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	// balances are totalled per currency, groups may keep their books in different ones
 	type BalanceSummary struct {
 		UserID    int             `json:"user_id"`
 		Username  string          `json:"username"`
 		SplitID   int             `json:"split_id"`
+		Currency  string          `json:"currency"`
 		TotalOwed decimal.Decimal `json:"total_owed"`
 	}
 
 	owesQuery := `
-		SELECT e.paid_by, u.username, s.id, SUM(s.amount_owed) AS total_owed
+		SELECT e.paid_by, u.username, s.id, COALESCE(gs.currency, 'NGN') AS currency, SUM(s.amount_owed) AS total_owed
 		FROM group_expense_splits s
 		JOIN group_expenses e ON s.expense_id = e.id
 		JOIN users u ON e.paid_by = u.id
+		LEFT JOIN group_settings gs ON gs.group_id = e.group_id
 		WHERE s.owed_by = ? AND s.is_settled = FALSE
-		GROUP BY e.paid_by, u.username;
+		GROUP BY e.paid_by, u.username, currency;
 	`
 
 	rows1, err := db.QueryContext(ctx, owesQuery, userID)
@@ -620,7 +872,7 @@ func GetUserBalanceSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	var owes []BalanceSummary
 	for rows1.Next() {
 		var b BalanceSummary
-		if err := rows1.Scan(&b.UserID, &b.Username, &b.SplitID, &b.TotalOwed); err != nil {
+		if err := rows1.Scan(&b.UserID, &b.Username, &b.SplitID, &b.Currency, &b.TotalOwed); err != nil {
 			utils.Logger.Errorf("error scanning owes summary: %v", err)
 			continue
 		}
@@ -628,12 +880,13 @@ func GetUserBalanceSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	isOwedQuery := `
-		SELECT s.owed_by, u.username, s.id, SUM(s.amount_owed) AS total_owed
+		SELECT s.owed_by, u.username, s.id, COALESCE(gs.currency, 'NGN') AS currency, SUM(s.amount_owed) AS total_owed
 		FROM group_expense_splits s
 		JOIN group_expenses e ON s.expense_id = e.id
 		JOIN users u ON s.owed_by = u.id
+		LEFT JOIN group_settings gs ON gs.group_id = e.group_id
 		WHERE e.paid_by = ? AND s.is_settled = FALSE
-		GROUP BY s.owed_by, u.username;
+		GROUP BY s.owed_by, u.username, currency;
 	`
 
 	rows2, err := db.QueryContext(ctx, isOwedQuery, userID)
@@ -646,7 +899,7 @@ func GetUserBalanceSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	var isOwed []BalanceSummary
 	for rows2.Next() {
 		var b BalanceSummary
-		if err := rows2.Scan(&b.UserID, &b.Username, &b.SplitID, &b.TotalOwed); err != nil {
+		if err := rows2.Scan(&b.UserID, &b.Username, &b.SplitID, &b.Currency, &b.TotalOwed); err != nil {
 			utils.Logger.Errorf("error scanning is_owed summary: %v", err)
 			continue
 		}
@@ -694,8 +947,12 @@ func GetGroupSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var groupName string
-	err = db.QueryRowContext(ctx, "SELECT name FROM groups WHERE id = ?", groupID).Scan(&groupName)
+	var groupName, currency string
+	err = db.QueryRowContext(ctx, `
+		SELECT g.name, COALESCE(gs.currency, 'NGN') FROM groups g
+		LEFT JOIN group_settings gs ON gs.group_id = g.id
+		WHERE g.id = ?
+	`, groupID).Scan(&groupName, &currency)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "group not found", http.StatusNotFound)
@@ -752,8 +1009,9 @@ func GetGroupSummaryHandler(w http.ResponseWriter, r *http.Request) {
 		"status": "success",
 		"data": map[string]interface{}{
 			"group": map[string]interface{}{
-				"id":   groupID,
-				"name": groupName,
+				"id":       groupID,
+				"name":     groupName,
+				"currency": currency,
 			},
 			"balances": balances,
 		},
@@ -790,7 +1048,9 @@ func SettleExpenseSplitHandler(w http.ResponseWriter, r *http.Request) {
 	userID := int(idFloat)
 
 	type request struct {
-		Amount decimal.Decimal `json:"amount"`
+		Amount           decimal.Decimal `json:"amount"`
+		Pin              string          `json:"pin"`
+		AllowOverpayment bool            `json:"allow_overpayment"`
 	}
 
 	var req request
@@ -802,12 +1062,22 @@ func SettleExpenseSplitHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		utils.WriteError(w, "amount must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	if !utils.HasAtMostTwoDecimals(req.Amount) {
+		utils.WriteError(w, "amount cannot have more than 2 decimal places", http.StatusBadRequest)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
 	defer cancel()
 
 	var split models.GroupExpenseSplit
-	err = db.QueryRowContext(ctx, "SELECT id, expense_id, owed_by, amount_owed, created_at FROM group_expense_splits WHERE id = ? AND is_settled = ?", splitID, "FALSE").
-		Scan(&split.ID, &split.ExpenseID, &split.OwedBy, &split.AmountOwed, &split.CreatedAt)
+	err = db.QueryRowContext(ctx, "SELECT id, expense_id, COALESCE(owed_by, 0), amount_owed FROM group_expense_splits WHERE id = ? AND is_settled = FALSE", splitID).
+		Scan(&split.ID, &split.ExpenseID, &split.OwedBy, &split.AmountOwed)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "expense split not found", http.StatusNotFound)
@@ -823,45 +1093,13 @@ func SettleExpenseSplitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payerWallet models.Wallet
-	err = db.QueryRowContext(ctx, "SELECT id, balance, last_funded_at, created_at, updated_at FROM wallets WHERE user_id = ?", userID).
-		Scan(&payerWallet.ID, &payerWallet.Balance, &payerWallet.LastFundedAt, &payerWallet.CreatedAt, &payerWallet.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			utils.WriteError(w, "error fetching wallet balance", http.StatusNotFound)
-			return
-		}
-		utils.Logger.Errorf("error fetching wallet balance: %v", err)
-		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	if req.Amount.GreaterThan(split.AmountOwed) && !req.AllowOverpayment {
+		utils.WriteError(w, fmt.Sprintf("amount exceeds the %s owed on this split, set allow_overpayment to keep the excess as credit", split.AmountOwed.StringFixed(2)), http.StatusBadRequest)
 		return
 	}
 
-	if payerWallet.Balance.LessThan(req.Amount) {
-		utils.WriteError(w, "insufficient funds in wallet, please fund wallet", http.StatusPaymentRequired)
-		return
-	}
-
-	var expense models.GroupExpense
-	err = db.QueryRowContext(ctx, "SELECT paid_by FROM group_expenses WHERE id = ?", split.ExpenseID).Scan(&expense.PaidBy)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			utils.WriteError(w, "expense not found", http.StatusNotFound)
-			return
-		}
-		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	var owedWallet models.Wallet
-	err = db.QueryRowContext(ctx, "SELECT id, balance, last_funded_at, created_at, updated_at FROM wallets WHERE user_id = ?", expense.PaidBy).
-		Scan(&owedWallet.ID, &owedWallet.Balance, &owedWallet.LastFundedAt, &owedWallet.CreatedAt, &owedWallet.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			utils.WriteError(w, "error fetching wallet balance", http.StatusNotFound)
-			return
-		}
-		utils.Logger.Errorf("error fetching wallet balance: %v", err)
-		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+		handlers.WritePinError(w, err)
 		return
 	}
 
@@ -872,77 +1110,11 @@ func SettleExpenseSplitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newPayerBalance := payerWallet.Balance.Sub(req.Amount)
-	newOwedBalance := owedWallet.Balance.Add(req.Amount)
-
-	_, err = tx.ExecContext(ctx, `
-		UPDATE wallets SET balance = ? WHERE id = ?
-	`, newPayerBalance, payerWallet.ID)
+	settlement, err := services.SettleSplit(ctx, tx, split.ID, userID, req.Amount, req.AllowOverpayment)
 	if err != nil {
 		tx.Rollback()
-		utils.Logger.Errorf("failed to update payer wallet: %v", err)
-		utils.WriteError(w, "failed to update payer wallet", http.StatusInternalServerError)
-		return
-	}
-
-	_, err = tx.ExecContext(ctx, `
-		UPDATE wallets SET balance = ?, last_funded_at = ? WHERE id = ?
-	`, newOwedBalance, time.Now().Format("2006-01-02 15:04:05"), owedWallet.ID)
-	if err != nil {
-		tx.Rollback()
-		utils.Logger.Errorf("failed to update owed wallet: %v", err)
-		utils.WriteError(w, "failed to update owed wallet", http.StatusInternalServerError)
-		return
-	}
-
-	isFullyPaid := req.Amount.Equal(split.AmountOwed)
-	if isFullyPaid {
-		_, err = tx.ExecContext(ctx, `
-			UPDATE group_expense_splits SET amount_owed = ?, is_settled = TRUE WHERE id = ?
-		`, 0, split.ID)
-		if err != nil {
-			tx.Rollback()
-			utils.Logger.Errorf("error marking split as settled: %v", err)
-			utils.WriteError(w, "failed to mark split as settled", http.StatusInternalServerError)
-			return
-		}
-	} else {
-		remaining := split.AmountOwed.Sub(req.Amount)
-		_, err = tx.ExecContext(ctx, `
-			UPDATE group_expense_splits SET amount_owed = ? WHERE id = ?
-		`, remaining, split.ID)
-		if err != nil {
-			tx.Rollback()
-			utils.Logger.Errorf("failed to update remaining amount: %v", err)
-			utils.WriteError(w, "failed to update remaining amount", http.StatusInternalServerError)
-			return
-		}
-	}
-
-	payerRef := fmt.Sprintf("splt-%s", utils.GenerateRandomString(10))
-	receiverRef := fmt.Sprintf("splt-%s", utils.GenerateRandomString(10))
-
-	// Payer transaction (DEBIT)
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description)
-		VALUES (?, 'debit', 'split', ?, 'success', ?, ?)
-	`, userID, req.Amount, payerRef, fmt.Sprintf("Payment for split #%d", split.ID))
-	if err != nil {
-		tx.Rollback()
-		utils.Logger.Errorf("failed to record payer transaction: %v", err)
-		utils.WriteError(w, "failed to record payer transaction", http.StatusInternalServerError)
-		return
-	}
-
-	// Recipient transaction (CREDIT)
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description)
-		VALUES (?, 'credit', 'split', ?, 'success', ?, ?)
-	`, expense.PaidBy, req.Amount, receiverRef, fmt.Sprintf("Received payment for split #%d", split.ID))
-	if err != nil {
-		tx.Rollback()
-		utils.Logger.Errorf("ailed to record recipient transaction: %v", err)
-		utils.WriteError(w, "failed to record recipient transaction", http.StatusInternalServerError)
+		utils.Logger.Errorf("failed to settle split %d: %v", split.ID, err)
+		handlers.WriteSettlementError(w, err)
 		return
 	}
 
@@ -952,32 +1124,24 @@ func SettleExpenseSplitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payerName, receiverEmail, groupName string
-	db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", userID).Scan(&payerName)
-	db.QueryRowContext(ctx, "SELECT email FROM users WHERE id = ?", expense.PaidBy).Scan(&receiverEmail)
-	db.QueryRowContext(ctx, `
-		SELECT g.name FROM groups g 
-		JOIN group_expenses e ON g.id = e.group_id 
-		WHERE e.id = ?`, split.ExpenseID).Scan(&groupName)
-
-	go func() {
-		if err := utils.SendPaymentReceivedEmail(receiverEmail, payerName, req.Amount.String(), groupName, split.ID, time.Now()); err != nil {
-			utils.Logger.Errorf("failed to send payment received email to %s: %v", receiverEmail, err)
-		}
-	}()
+	services.NotifySplitPayment(ctx, db, userID, settlement)
 
 	message := "split payment recorded"
-	if isFullyPaid {
+	if settlement.IsFullyPaid {
 		message = "split fully settled"
 	}
+	if settlement.Credit.GreaterThan(decimal.Zero) {
+		message = fmt.Sprintf("split fully settled, %s kept as credit towards future splits", settlement.Credit.StringFixed(2))
+	}
 
 	utils.WriteJSON(w, map[string]interface{}{
 		"status":  "success",
 		"message": message,
 		"data": map[string]interface{}{
-			"amount_paid":      req.Amount,
-			"remaining_owed":   split.AmountOwed.Sub(req.Amount),
-			"is_fully_settled": isFullyPaid,
+			"amount_paid":      settlement.AmountPaid,
+			"remaining_owed":   settlement.Remaining,
+			"is_fully_settled": settlement.IsFullyPaid,
+			"credit":           settlement.Credit,
 		},
 	})
 }
@@ -1024,13 +1188,42 @@ func DeleteExpenseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if expense.PaidBy != userID {
-		utils.WriteError(w, "you are not authorized to delete this expense entry", http.StatusUnauthorized)
+	if expense.PaidBy != userID && !authorizeGroupAction(ctx, w, db, expense.GroupID, userID, actionEditOthersExpense) {
+		return
+	}
+
+	archived, err := groupIsArchived(ctx, db, expense.GroupID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if archived {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
 		return
 	}
 
-	res, err := db.ExecContext(ctx, "DELETE FROM group_expenses WHERE id = ?", expenseID)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	hasPayments, err := expenseHasPayments(ctx, tx, expenseID)
+	if err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if hasPayments {
+		tx.Rollback()
+		utils.WriteError(w, errExpenseHasPayments.Error(), http.StatusConflict)
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM group_expenses WHERE id = ?", expenseID)
+	if err != nil {
+		tx.Rollback()
 		utils.Logger.Error("unable to delete")
 		utils.WriteError(w, "error deleting expense", http.StatusInternalServerError)
 		return
@@ -1038,11 +1231,24 @@ func DeleteExpenseHandler(w http.ResponseWriter, r *http.Request) {
 
 	rowsAffected, _ := res.RowsAffected()
 	if rowsAffected == 0 {
+		tx.Rollback()
 		utils.Logger.Errorf("error deleting expense: %v", err)
 		utils.WriteError(w, "expense not found or already deleted", http.StatusNotFound)
 		return
 	}
 
+	if err := syncGroupTotal(ctx, tx, expense.GroupID); err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to update group total: %v", err)
+		utils.WriteError(w, "error deleting expense", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	utils.WriteJSON(w, map[string]interface{}{
 		"status":  "success",
 		"message": "expense deleted successfully",
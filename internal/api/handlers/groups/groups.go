@@ -11,8 +11,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"qiyana_paybuddy/internal/api/handlers"
 	"qiyana_paybuddy/internal/models"
 	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
 	"qiyana_paybuddy/pkg/utils"
 	"strconv"
 	"strings"
@@ -75,8 +77,8 @@ func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := `INSERT INTO groups (name, description, created_by) VALUES (?, ?, ?)`
-	res, err := tx.ExecContext(ctx, query, newGroup.Name, newGroup.Description, userID)
+	query := `INSERT INTO groups (name, description, created_by, is_public) VALUES (?, ?, ?, ?)`
+	res, err := tx.ExecContext(ctx, query, newGroup.Name, newGroup.Description, userID, newGroup.IsPublic)
 	if err != nil {
 		tx.Rollback()
 		utils.Logger.Errorf("failed to create group: %v", err)
@@ -114,6 +116,7 @@ func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
 		"data": map[string]interface{}{
 			"group_id":   id,
 			"group_name": newGroup.Name,
+			"is_public":  newGroup.IsPublic,
 			"role":       "admin",
 		},
 	}
@@ -155,6 +158,7 @@ func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
 	type request struct {
 		Name        string `json:"name"`
 		Description string `json:"description"`
+		IsPublic    *bool  `json:"is_public"`
 	}
 
 	var req request
@@ -179,18 +183,18 @@ func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var createdBy int
-	err = db.QueryRowContext(ctx, "SELECT created_by FROM groups WHERE id = ?", id).Scan(&createdBy)
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM groups WHERE id = ?)", id).Scan(&exists)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			utils.WriteError(w, "group not found", http.StatusNotFound)
-			return
-		}
 		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		utils.WriteError(w, "group not found", http.StatusNotFound)
+		return
 	}
 
-	if createdBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if !authorizeGroupAction(ctx, w, db, id, userID, actionManageGroup) {
 		return
 	}
 
@@ -206,6 +210,10 @@ func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
 		fields = append(fields, "description = ?")
 		args = append(args, req.Description)
 	}
+	if req.IsPublic != nil {
+		fields = append(fields, "is_public = ?")
+		args = append(args, *req.IsPublic)
+	}
 
 	if len(fields) == 0 {
 		utils.WriteError(w, "no updates provided", http.StatusBadRequest)
@@ -231,7 +239,7 @@ func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// FUNC TO GET ALL GROUPS CREATED BY THE LOGGED-IN ADMIN
+// FUNC TO GET ALL GROUPS THE LOGGED-IN USER ADMINISTERS
 func GetMyGroupsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -252,15 +260,21 @@ func GetMyGroupsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	userID := int(idFloat)
 
+	listQuery, err := groupSpec.Parse(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	query := `
-		SELECT id, name, description, created_by, total_expense, created_at
+		SELECT id, name, description, created_by, total_expense, created_at, archived_at
 		FROM groups
-		WHERE created_by = ?
-	`
-	args := []interface{}{userID}
-
-	query, args = utils.AddFilters(r, query, args)
-	query = utils.AddSorting(r, query)
+		WHERE id IN (SELECT group_id FROM group_members WHERE user_id = ? AND role = 'admin')`
+	if r.URL.Query().Get("include_archived") != "true" {
+		query += " AND archived_at IS NULL"
+	}
+	query += listQuery.Where + listQuery.OrderBy()
+	args := append([]interface{}{userID}, listQuery.Args...)
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
@@ -273,7 +287,7 @@ func GetMyGroupsHandler(w http.ResponseWriter, r *http.Request) {
 	groupList := make([]models.Group, 0)
 	for rows.Next() {
 		var group models.Group
-		err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedBy, &group.TotalExpense, &group.CreatedAt)
+		err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedBy, &group.TotalExpense, &group.CreatedAt, &group.ArchivedAt)
 		if err != nil {
 			utils.Logger.Errorf("error fetching data: %v", err)
 			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
@@ -344,12 +358,12 @@ func GetGroupByIDHandler(w http.ResponseWriter, r *http.Request) {
 
 	var group models.Group
 	err = db.QueryRow(`
-        SELECT id, name, description, created_by, total_expense, created_at, updated_at
+        SELECT id, name, description, created_by, total_expense, created_at, updated_at, archived_at, is_public
         FROM groups WHERE id = ?
     `, groupID).Scan(
 		&group.ID, &group.Name, &group.Description,
 		&group.CreatedBy, &group.TotalExpense,
-		&group.CreatedAt, &group.UpdatedAt,
+		&group.CreatedAt, &group.UpdatedAt, &group.ArchivedAt, &group.IsPublic,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -516,44 +530,67 @@ func DeleteGroupByHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var createdBy int
-	err = db.QueryRowContext(ctx, "SELECT created_by FROM groups WHERE id = ?", groupID).Scan(&createdBy)
+	var archivedAt sql.NullString
+	err = db.QueryRowContext(ctx, "SELECT archived_at FROM groups WHERE id = ?", groupID).Scan(&archivedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "group not found", http.StatusNotFound)
 			return
 		}
 		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
 	}
 
-	if createdBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if archivedAt.Valid {
+		utils.WriteError(w, "group is already archived", http.StatusConflict)
 		return
 	}
 
-	res, err := db.ExecContext(ctx, "DELETE FROM groups WHERE id = ?", groupID)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		utils.Logger.Errorf("error deleting data: %v", err)
-		utils.WriteError(w, "error deleting group", http.StatusInternalServerError)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
 
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		utils.Logger.Errorf("error deleting data: %v", err)
-		utils.WriteError(w, "group not found or already deleted", http.StatusNotFound)
+	// only what is left once debts running both ways cancel out holds the group open
+	debts, err := services.OutstandingDebts(ctx, tx, groupID, 0)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	debts, _, err = services.OffsetDebts(ctx, tx, debts, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to offset debts in group %d: %v", groupID, err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if len(debts) > 0 {
+		writeOutstandingDebts(w, "group has outstanding debts between members", debts)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	response := map[string]interface{}{
-		"status":  "success",
-		"message": "group and its members deleted successfully",
+	// deleting only archives the group; its history stays readable until an admin purges it
+	_, err = tx.ExecContext(ctx, "UPDATE groups SET archived_at = NOW(), archived_by = ? WHERE id = ? AND archived_at IS NULL", userID, groupID)
+	if err != nil {
+		utils.Logger.Errorf("error archiving group: %v", err)
+		utils.WriteError(w, "error archiving group", http.StatusInternalServerError)
+		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "error archiving group", http.StatusInternalServerError)
+		return
+	}
 
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "group archived; an admin can restore it or request a permanent purge",
+	})
 }
 
 // FUNC TO INVITE MEMBERS TO GROUP
@@ -584,8 +621,11 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	userID := int(idFloat)
 
+	// registered users can be invited by username instead of email
 	type InviteRequest struct {
-		Email string `json:"email"`
+		Email         string `json:"email"`
+		Username      string `json:"username"`
+		AllowAnyEmail bool   `json:"allow_any_email"`
 	}
 
 	var invites []InviteRequest
@@ -617,20 +657,37 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var group models.Group
-	err = tx.QueryRowContext(ctx, "SELECT name, description, created_by FROM groups WHERE id = ?", groupID).
-		Scan(&group.Name, &group.Description, &group.CreatedBy)
+	err = tx.QueryRowContext(ctx, "SELECT name, description, created_by, archived_at FROM groups WHERE id = ?", groupID).
+		Scan(&group.Name, &group.Description, &group.CreatedBy, &group.ArchivedAt)
 	if err != nil {
 		tx.Rollback()
 		utils.WriteError(w, "group not found", http.StatusNotFound)
 		return
 	}
 
-	if group.CreatedBy != userID {
+	if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionInvite) {
 		tx.Rollback()
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
 		return
 	}
 
+	if group.ArchivedAt.Valid {
+		tx.Rollback()
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	// letting someone other than the invited address accept is an admin decision
+	for _, inv := range invites {
+		if !inv.AllowAnyEmail {
+			continue
+		}
+		if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionManageGroup) {
+			tx.Rollback()
+			return
+		}
+		break
+	}
+
 	durationDays, err := strconv.Atoi(os.Getenv("INVITE_TOKEN_EXP_DURATION"))
 	if err != nil {
 		tx.Rollback()
@@ -642,8 +699,11 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 	expiry := expiryTime.UTC().Format("2006-01-02 15:04:05")
 
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO group_invitations (group_id, email, token, invited_by, expires_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO group_invitations (group_id, email, token, invited_by, expires_at, allow_any_email, channel, invited_user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), token = VALUES(token), invited_by = VALUES(invited_by),
+			expires_at = VALUES(expires_at), allow_any_email = VALUES(allow_any_email), channel = VALUES(channel),
+			invited_user_id = VALUES(invited_user_id), status = 'pending', responded_at = NULL, created_at = NOW()
 	`)
 	if err != nil {
 		tx.Rollback()
@@ -658,28 +718,55 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 	var skippedDetails []map[string]string
 
 	for _, inv := range invites {
-		email := strings.TrimSpace(inv.Email)
-		if email == "" {
+		email := strings.ToLower(strings.TrimSpace(inv.Email))
+		username := strings.ToLower(strings.TrimSpace(inv.Username))
+		if (email == "") == (username == "") {
 			skippedInvites++
 			skippedDetails = append(skippedDetails, map[string]string{
-				"email":  email,
-				"reason": "email is empty or invalid",
+				"email":    email,
+				"username": username,
+				"reason":   "provide either an email or a username",
 			})
 			continue
 		}
 
+		channel := "email"
+		var invitedUserID sql.NullInt64
+		if username != "" {
+			channel = "username"
+			err = tx.QueryRowContext(ctx, "SELECT id, email FROM users WHERE username = ?", username).Scan(&invitedUserID, &email)
+			if err == sql.ErrNoRows {
+				skippedInvites++
+				skippedDetails = append(skippedDetails, map[string]string{
+					"username": username,
+					"reason":   "no user with this username",
+				})
+				continue
+			}
+			if err != nil {
+				tx.Rollback()
+				utils.Logger.Errorf("failed to look up invited username %s: %v", username, err)
+				utils.WriteError(w, "failed to save invites", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// a username invite must not reveal the email it was looked up to
+		invitee := map[string]string{"email": email}
+		if channel == "username" {
+			invitee = map[string]string{"username": username}
+		}
+
 		var exists bool
 		err = tx.QueryRowContext(ctx, `
 			SELECT EXISTS(
-				SELECT 1 FROM group_invitations WHERE group_id = ? AND email = ?
+				SELECT 1 FROM group_invitations WHERE group_id = ? AND email = ? AND status = 'pending'
 			)
 		`, groupID, email).Scan(&exists)
 		if err == nil && exists {
 			skippedInvites++
-			skippedDetails = append(skippedDetails, map[string]string{
-				"email":  email,
-				"reason": "user already invited to this group, use the resend invite endpoint",
-			})
+			invitee["reason"] = "user already invited to this group, use the resend invite endpoint"
+			skippedDetails = append(skippedDetails, invitee)
 			continue
 		}
 
@@ -692,10 +779,47 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 		`, groupID, email).Scan(&exists)
 		if err == nil && exists {
 			skippedInvites++
-			skippedDetails = append(skippedDetails, map[string]string{
-				"email":  email,
-				"reason": "user is already a group member",
+			invitee["reason"] = "user is already a group member"
+			skippedDetails = append(skippedDetails, invitee)
+			continue
+		}
+
+		var registered bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", email).Scan(&registered)
+		if err != nil {
+			tx.Rollback()
+			utils.Logger.Errorf("failed to look up invited email %s: %v", email, err)
+			utils.WriteError(w, "failed to save invites", http.StatusInternalServerError)
+			return
+		}
+
+		// username invites are answered from the invitation inbox, so they carry no token
+		if channel == "username" {
+			res, err := stmt.ExecContext(ctx, groupID, email, nil, userID, expiry, inv.AllowAnyEmail, channel, invitedUserID)
+			if err != nil {
+				tx.Rollback()
+				utils.Logger.Errorf("failed to insert invitation for %s: %v", username, err)
+				utils.WriteError(w, "failed to save invites", http.StatusInternalServerError)
+				return
+			}
+			inviteID, _ := res.LastInsertId()
+
+			err = services.Notify(ctx, tx, models.Notification{
+				UserID:      int(invitedUserID.Int64),
+				Type:        "group_invite",
+				Message:     fmt.Sprintf("You have been invited to join %s", group.Name),
+				GroupID:     sql.NullInt64{Int64: int64(groupID), Valid: true},
+				ReferenceID: sql.NullInt64{Int64: inviteID, Valid: true},
 			})
+			if err != nil {
+				tx.Rollback()
+				utils.Logger.Errorf("failed to notify %s of invitation: %v", username, err)
+				utils.WriteError(w, "failed to save invites", http.StatusInternalServerError)
+				return
+			}
+
+			addedInvites++
+			successfulInvites = append(successfulInvites, username)
 			continue
 		}
 
@@ -711,7 +835,7 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 		hashedToken := sha256.Sum256(tokenBytes)
 		hashedTokenString := hex.EncodeToString(hashedToken[:])
 
-		_, err = stmt.ExecContext(ctx, groupID, email, hashedTokenString, userID, expiry)
+		_, err = stmt.ExecContext(ctx, groupID, email, hashedTokenString, userID, expiry, inv.AllowAnyEmail, channel, invitedUserID)
 		if err != nil {
 			tx.Rollback()
 			utils.Logger.Errorf("failed to insert invitation for %s: %v", email, err)
@@ -721,14 +845,7 @@ func InviteMembersHandler(w http.ResponseWriter, r *http.Request) {
 		addedInvites++
 		successfulInvites = append(successfulInvites, email)
 
-		inviteLink := fmt.Sprintf("https://localhost:3000/groups/invite/%s", token)
-		go func(email string, link string) {
-			time.AfterFunc(500*time.Millisecond, func() {
-				if err := utils.SendGroupInviteEmail(email, group.Name, group.Description, link, expiryTime); err != nil {
-					utils.Logger.Errorf("failed to send invite email to %s: %v", email, err)
-				}
-			})
-		}(email, inviteLink)
+		sendInviteEmail(email, registered, group, token, expiryTime)
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -784,8 +901,8 @@ func AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
 	hashedToken := sha256.Sum256(bytes)
 	hashedTokenString := hex.EncodeToString(hashedToken[:])
 
-	var id int
-	err = db.QueryRow("SELECT id FROM users WHERE id = ?", userID).Scan(&id)
+	var email string
+	err = db.QueryRow("SELECT email FROM users WHERE id = ?", userID).Scan(&email)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "user not found, please sign up", http.StatusNotFound)
@@ -797,8 +914,8 @@ func AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var groupInvite models.GroupInvitation
-	query := "SELECT id, group_id, email, status FROM group_invitations WHERE token = ? AND expires_at > ?"
-	err = db.QueryRow(query, hashedTokenString, time.Now().Format("2006-01-02 15:04:05")).Scan(&groupInvite.ID, &groupInvite.GroupID, &groupInvite.Email, &groupInvite.Status)
+	query := "SELECT id, group_id, email, status, allow_any_email FROM group_invitations WHERE token = ? AND expires_at > ?"
+	err = db.QueryRow(query, hashedTokenString, time.Now().Format("2006-01-02 15:04:05")).Scan(&groupInvite.ID, &groupInvite.GroupID, &groupInvite.Email, &groupInvite.Status, &groupInvite.AllowAnyEmail)
 	if err != nil {
 		utils.WriteError(w, "invite token expired or invalid", http.StatusBadRequest)
 		return
@@ -819,39 +936,41 @@ func AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var exists int
-	checkMemberQuery := "SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?"
-	err = db.QueryRow(checkMemberQuery, groupInvite.GroupID, userID).Scan(&exists)
-	if err != nil {
-		utils.Logger.Errorf("failed to check existing membership: %v", err)
-		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	if groupInvite.Status == "declined" {
+		utils.WriteError(w, "invitation already declined", http.StatusBadRequest)
 		return
 	}
-	if exists > 0 {
-		utils.WriteError(w, "you are already a member of this group", http.StatusBadRequest)
+
+	// a forwarded link is only good for someone else if an admin allowed it
+	if !groupInvite.AllowAnyEmail && !strings.EqualFold(email, groupInvite.Email) {
+		utils.WriteError(w, "this invitation was sent to a different email address", http.StatusForbidden)
 		return
 	}
 
-	tx, err := db.Begin()
+	archived, err := groupIsArchived(r.Context(), db, groupInvite.GroupID)
 	if err != nil {
-		utils.Logger.Errorf("failed to start transaction: %v", err)
 		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
+	if archived {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
 
-	deleteQuery := "DELETE FROM group_invitations WHERE id = ?"
-	_, err = tx.Exec(deleteQuery, groupInvite.ID)
+	tx, err := db.Begin()
 	if err != nil {
-		tx.Rollback()
-		utils.Logger.Errorf("error deleting invite: %v", err)
-		utils.WriteError(w, "unable to join group at the moment, please try again later!", http.StatusInternalServerError)
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	insertMemberQuery := `INSERT INTO group_members (group_id, user_id, role) VALUES (?, ?, 'member')`
-	_, err = tx.Exec(insertMemberQuery, groupInvite.GroupID, userID)
+	err = redeemInvitation(r.Context(), tx, groupInvite.ID, groupInvite.GroupID, userID)
 	if err != nil {
 		tx.Rollback()
+		if err == errAlreadyMember {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.Logger.Errorf("failed to join group: %v", err)
 		utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
 		return
@@ -901,7 +1020,10 @@ func RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
 	userID := int(idFloat)
 
 	type request struct {
-		ID int `json:"id"`
+		ID       int    `json:"id"`
+		SettleUp bool   `json:"settle_up"`
+		WriteOff bool   `json:"write_off"`
+		Pin      string `json:"pin"`
 	}
 
 	var req request
@@ -920,8 +1042,7 @@ func RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if group.CreatedBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if !authorizeGroupAction(r.Context(), w, db, groupID, userID, actionManageGroup) {
 		return
 	}
 
@@ -937,22 +1058,59 @@ func RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.ID == userID {
-		utils.WriteError(w, "group admins cannot leave. Transfer ownership or delete the group.", http.StatusBadRequest)
+		utils.WriteError(w, "you cannot remove yourself; leave the group instead", http.StatusBadRequest)
 		return
 	}
 
-	_, err = db.Exec("DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, req.ID)
+	if req.ID == group.CreatedBy {
+		utils.WriteError(w, "the group owner cannot be removed", http.StatusForbidden)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	// settle_up pays what the admin owes the member; write_off forgives what the member owes the admin.
+	// debts the member has with anyone else must be cleared by those members first
+	if req.SettleUp {
+		if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+			handlers.WritePinError(w, err)
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	resolution, ok := resolveDebtsBeforeExit(ctx, w, tx, groupID, req.ID, userID, req.SettleUp, req.WriteOff, "member removed from group")
+	if !ok {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, req.ID)
+	if err != nil {
+		tx.Rollback()
 		utils.Logger.Errorf("failed to remove member: %v", err)
 		utils.WriteError(w, "failed to remove member", http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to remove member", http.StatusInternalServerError)
+		return
+	}
+
+	notifyExitSettlements(ctx, db, userID, resolution)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":  "success",
 		"message": "member removed successfully",
+		"data":    exitSummary(resolution),
 	})
 }
 
@@ -1004,22 +1162,89 @@ func LeaveGroupHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if group.CreatedBy == userID {
-		utils.WriteError(w, "group admins cannot leave. Transfer ownership or delete the group.", http.StatusBadRequest)
+		utils.WriteError(w, "the group owner cannot leave. Transfer ownership or delete the group.", http.StatusBadRequest)
+		return
+	}
+
+	type request struct {
+		SettleUp bool   `json:"settle_up"`
+		WriteOff bool   `json:"write_off"`
+		Pin      string `json:"pin"`
+	}
+
+	// the body is optional: without it the member can only leave once they are square.
+	// settle_up pays what they owe from their wallet; write_off forgives what they are owed.
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&req); err != nil && err != io.EOF {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if req.SettleUp {
+		if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+			handlers.WritePinError(w, err)
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	var role string
+	if err := tx.QueryRowContext(ctx, "SELECT role FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&role); err != nil {
+		tx.Rollback()
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if role == "admin" {
+		last, err := lastAdmin(ctx, tx, groupID, userID)
+		if err != nil {
+			tx.Rollback()
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if last {
+			tx.Rollback()
+			utils.WriteError(w, "you are the last admin; promote another member before leaving", http.StatusConflict)
+			return
+		}
+	}
+
+	resolution, ok := resolveDebtsBeforeExit(ctx, w, tx, groupID, userID, userID, req.SettleUp, req.WriteOff, "member left group")
+	if !ok {
 		return
 	}
 
-	_, err = db.Exec("DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID)
 	if err != nil {
+		tx.Rollback()
 		utils.Logger.Errorf("failed to leave group: %v", err)
 		utils.WriteError(w, "failed to leave group", http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to leave group", http.StatusInternalServerError)
+		return
+	}
+
+	notifyExitSettlements(ctx, db, userID, resolution)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status":  "success",
 		"message": "you have successfully left the group",
+		"data":    exitSummary(resolution),
 	})
 }
 
@@ -1059,24 +1284,34 @@ func ListPendingInvitesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if group.CreatedBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if !authorizeGroupAction(r.Context(), w, db, groupID, userID, actionManageGroup) {
 		return
 	}
 
 	page, limit := utils.GetPaginationParams(r)
 	offset := (page - 1) * limit
 
-	query := `
-		SELECT id, group_id, email, status, invited_by, expires_at, created_at
-		FROM group_invitations
-		WHERE group_id = ? AND status = ?
-	`
-	args := []interface{}{groupID, "pending"}
+	listQuery, err := invitationSpec.Parse(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	query = utils.AddSorting(r, query)
+	// answered invitations stay on record so admins can see who said yes or no
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "declined" && status != "accepted" {
+		utils.WriteError(w, "status must be pending, declined or accepted", http.StatusBadRequest)
+		return
+	}
 
-	query += " LIMIT ? OFFSET ?"
+	query := `
+		SELECT id, group_id, email, status, allow_any_email, invited_by, expires_at, responded_at, created_at
+		FROM group_invitations
+		WHERE group_id = ? AND status = ?` + listQuery.Where + listQuery.OrderBy() + " LIMIT ? OFFSET ?"
+	args := append([]interface{}{groupID, status}, listQuery.Args...)
 	args = append(args, limit, offset)
 
 	rows, err := db.Query(query, args...)
@@ -1094,8 +1329,10 @@ func ListPendingInvitesHandler(w http.ResponseWriter, r *http.Request) {
 			&invite.GroupID,
 			&invite.Email,
 			&invite.Status,
+			&invite.AllowAnyEmail,
 			&invite.InvitedBy,
 			&invite.ExpiresAt,
+			&invite.RespondedAt,
 			&invite.CreatedAt,
 		)
 		if err != nil {
@@ -1114,7 +1351,7 @@ func ListPendingInvitesHandler(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		response := map[string]interface{}{
 			"status":  "success",
-			"message": "no pending invitations found",
+			"message": "no " + status + " invitations found",
 			"data":    []models.GroupInvitation{},
 		}
 		json.NewEncoder(w).Encode(response)
@@ -1183,14 +1420,13 @@ func GetOnePendingInviteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if group.CreatedBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if !authorizeGroupAction(r.Context(), w, db, groupID, userID, actionManageGroup) {
 		return
 	}
 
 	var invite models.GroupInvitation
 	err = db.QueryRow(`
-		SELECT id, group_id, email, status, invited_by, expires_at, created_at
+		SELECT id, group_id, email, status, allow_any_email, invited_by, expires_at, created_at
 		FROM group_invitations
 		WHERE id = ? AND group_id = ? AND status = 'pending'
 	`, inviteID, groupID).Scan(
@@ -1198,6 +1434,7 @@ func GetOnePendingInviteHandler(w http.ResponseWriter, r *http.Request) {
 		&invite.GroupID,
 		&invite.Email,
 		&invite.Status,
+		&invite.AllowAnyEmail,
 		&invite.InvitedBy,
 		&invite.ExpiresAt,
 		&invite.CreatedAt,
@@ -1259,20 +1496,25 @@ func ResendInviteHandler(w http.ResponseWriter, r *http.Request) {
 	userID := int(idFloat)
 
 	var group models.Group
-	err = db.QueryRow("SELECT name, description, created_by FROM groups WHERE id = ?", groupID).
-		Scan(&group.Name, &group.Description, &group.CreatedBy)
+	err = db.QueryRow("SELECT name, description, created_by, archived_at FROM groups WHERE id = ?", groupID).
+		Scan(&group.Name, &group.Description, &group.CreatedBy, &group.ArchivedAt)
 	if err != nil {
 		utils.WriteError(w, "group not found", http.StatusNotFound)
 		return
 	}
 
-	if group.CreatedBy != userID {
-		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+	if !authorizeGroupAction(r.Context(), w, db, groupID, userID, actionInvite) {
+		return
+	}
+
+	if group.ArchivedAt.Valid {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
 		return
 	}
 
 	var invite models.GroupInvitation
-	err = db.QueryRow(`SELECT id, group_id, email, status FROM group_invitations WHERE id = ? AND group_id = ?`, inviteID, groupID).Scan(&invite.ID, &invite.GroupID, &invite.Email, &invite.Status)
+	err = db.QueryRow(`SELECT id, group_id, email, status, channel, invited_user_id FROM group_invitations WHERE id = ? AND group_id = ?`, inviteID, groupID).
+		Scan(&invite.ID, &invite.GroupID, &invite.Email, &invite.Status, &invite.Channel, &invite.InvitedUserID)
 	if err == sql.ErrNoRows {
 		utils.WriteError(w, "invitation not found", http.StatusNotFound)
 		return
@@ -1295,6 +1537,38 @@ func ResendInviteHandler(w http.ResponseWriter, r *http.Request) {
 	expiryTime := time.Now().Add(time.Hour * 24 * time.Duration(durationDays))
 	expiry := expiryTime.UTC().Format("2006-01-02 15:04:05")
 
+	// username invites have no token; resending extends them and notifies again
+	if invite.Channel == "username" && invite.InvitedUserID.Valid {
+		_, err = db.Exec("UPDATE group_invitations SET created_at = NOW(), expires_at = ? WHERE id = ? AND group_id = ?", expiry, inviteID, groupID)
+		if err != nil {
+			utils.WriteError(w, "failed to update invitation", http.StatusInternalServerError)
+			return
+		}
+
+		err = services.Notify(r.Context(), db, models.Notification{
+			UserID:      int(invite.InvitedUserID.Int64),
+			Type:        "group_invite",
+			Message:     fmt.Sprintf("Reminder: you have been invited to join %s", group.Name),
+			GroupID:     sql.NullInt64{Int64: int64(groupID), Valid: true},
+			ReferenceID: sql.NullInt64{Int64: int64(inviteID), Valid: true},
+		})
+		if err != nil {
+			utils.Logger.Errorf("failed to notify user %d of invitation: %v", invite.InvitedUserID.Int64, err)
+		}
+
+		utils.WriteJSON(w, map[string]interface{}{
+			"status":  "success",
+			"message": "invitation resent successfully",
+			"data": map[string]interface{}{
+				"invite_id":  inviteID,
+				"group_id":   groupID,
+				"email":      invite.Email,
+				"expires_at": expiryTime,
+			},
+		})
+		return
+	}
+
 	tokenBytes := make([]byte, 32)
 	_, err = rand.Read(tokenBytes)
 	if err != nil {
@@ -1316,14 +1590,11 @@ func ResendInviteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	inviteLink := fmt.Sprintf("https://localhost:3000/groups/invite/%s", token)
-	go func(email string, link string) {
-		time.AfterFunc(500*time.Millisecond, func() {
-			if err := utils.SendGroupInviteEmail(email, group.Name, group.Description, link, expiryTime); err != nil {
-				utils.Logger.Errorf("failed to send invite email to %s: %v", email, err)
-			}
-		})
-	}(invite.Email, inviteLink)
+	var registered bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", invite.Email).Scan(&registered); err != nil {
+		utils.Logger.Errorf("failed to look up invited email %s: %v", invite.Email, err)
+	}
+	sendInviteEmail(invite.Email, registered, group, token, expiryTime)
 
 	response := map[string]interface{}{
 		"status":  "success",
@@ -1361,14 +1632,25 @@ func RevokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var exists bool
-	err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM group_invitations WHERE id = ?)", inviteID).Scan(&exists)
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	var groupID int
+	err = db.QueryRow("SELECT group_id FROM group_invitations WHERE id = ?", inviteID).Scan(&groupID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "invitation not found", http.StatusNotFound)
+			return
+		}
 		utils.WriteError(w, "failed to check invitation", http.StatusInternalServerError)
 		return
 	}
-	if !exists {
-		utils.WriteError(w, "invitation not found", http.StatusNotFound)
+
+	if !authorizeGroupAction(r.Context(), w, db, groupID, userID, actionManageGroup) {
 		return
 	}
 
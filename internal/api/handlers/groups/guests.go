@@ -0,0 +1,887 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"os"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const guestColumns = "g.id, g.group_id, g.display_name, g.email, g.created_by, g.merged_into_user_id, g.merged_at, g.created_at"
+
+func scanGuest(row interface{ Scan(...interface{}) error }) (*models.GroupGuest, error) {
+	var guest models.GroupGuest
+	err := row.Scan(&guest.ID, &guest.GroupID, &guest.DisplayName, &guest.Email, &guest.CreatedBy,
+		&guest.MergedIntoUserID, &guest.MergedAt, &guest.CreatedAt, &guest.Outstanding)
+	if err != nil {
+		return nil, err
+	}
+	return &guest, nil
+}
+
+// guestQuery selects guests together with what they still owe
+const guestQuery = `
+	SELECT ` + guestColumns + `,
+		COALESCE((SELECT SUM(s.amount_owed) FROM group_expense_splits s
+			WHERE s.guest_id = g.id AND s.owed_by IS NULL AND s.is_settled = FALSE), 0)
+	FROM group_guests g`
+
+// activeGuestIDs returns the guests who are split into new expenses in the group
+func activeGuestIDs(ctx context.Context, tx *sql.Tx, groupID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM group_guests WHERE group_id = ? AND merged_into_user_id IS NULL AND removed_at IS NULL", groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guestIDs []int
+	for rows.Next() {
+		var guestID int
+		if err := rows.Scan(&guestID); err != nil {
+			return nil, err
+		}
+		guestIDs = append(guestIDs, guestID)
+	}
+	return guestIDs, rows.Err()
+}
+
+// insertGuestSplits gives each guest an equal share of an expense. Guests hold
+// no credits, so nothing offsets their splits.
+func insertGuestSplits(ctx context.Context, tx *sql.Tx, expenseID int64, guestIDs []int, share decimal.Decimal) error {
+	for _, guestID := range guestIDs {
+		_, err := tx.ExecContext(ctx, `INSERT INTO group_expense_splits (expense_id, guest_id, share_amount, amount_owed, is_settled) VALUES (?, ?, ?, ?, FALSE)`,
+			expenseID, guestID, share, share)
+		if err != nil {
+			return utils.ErrorHandler(err, "failed to split expense with guests")
+		}
+	}
+	return nil
+}
+
+// mergeGuests hands the splits of every active guest in the group added with
+// the user's email over to the user and marks those guests as merged into them
+func mergeGuests(ctx context.Context, tx *sql.Tx, groupID, userID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE group_expense_splits s
+		JOIN group_guests g ON g.id = s.guest_id
+		JOIN users u ON u.id = ? AND u.email = g.email
+		SET s.owed_by = u.id
+		WHERE g.group_id = ? AND g.merged_into_user_id IS NULL AND g.removed_at IS NULL
+	`, userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_guests g
+		JOIN users u ON u.id = ? AND u.email = g.email
+		SET g.merged_into_user_id = u.id, g.merged_at = NOW()
+		WHERE g.group_id = ? AND g.merged_into_user_id IS NULL AND g.removed_at IS NULL
+	`, userID, groupID)
+	return err
+}
+
+// InviteGuestUser sends a newly verified user an inbox invitation from every
+// group that added them as a guest under their email. Nothing is merged until
+// they accept, since anyone can add a guest under any email. It returns the
+// groups that invited them.
+func InviteGuestUser(ctx context.Context, db *sql.DB, userID int, email string) ([]int, error) {
+	durationDays, err := strconv.Atoi(os.Getenv("INVITE_TOKEN_EXP_DURATION"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid invite token duration: %w", err)
+	}
+	expiry := time.Now().Add(time.Hour * 24 * time.Duration(durationDays)).UTC().Format("2006-01-02 15:04:05")
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// members already merged their guests when they joined
+	rows, err := tx.QueryContext(ctx, `
+		SELECT g.group_id, gr.name, COALESCE(MIN(g.created_by), gr.created_by)
+		FROM group_guests g
+		JOIN groups gr ON gr.id = g.group_id
+		WHERE g.email = ? AND g.merged_into_user_id IS NULL AND g.removed_at IS NULL AND gr.archived_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM group_members m WHERE m.group_id = g.group_id AND m.user_id = ?)
+		GROUP BY g.group_id, gr.name, gr.created_by
+	`, email, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type guestGroup struct {
+		id, invitedBy int
+		name          string
+	}
+	var pending []guestGroup
+	for rows.Next() {
+		var g guestGroup
+		if err := rows.Scan(&g.id, &g.name, &g.invitedBy); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending = append(pending, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// like username invites these carry no token and are answered from the inbox
+	invited := make([]int, 0, len(pending))
+	for _, g := range pending {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO group_invitations (group_id, email, token, invited_by, expires_at, allow_any_email, channel, invited_user_id)
+			VALUES (?, ?, NULL, ?, ?, FALSE, 'username', ?)
+			ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), token = VALUES(token), invited_by = VALUES(invited_by),
+				expires_at = VALUES(expires_at), allow_any_email = VALUES(allow_any_email), channel = VALUES(channel),
+				invited_user_id = VALUES(invited_user_id), status = 'pending', responded_at = NULL, created_at = NOW()
+		`, g.id, email, g.invitedBy, expiry, userID)
+		if err != nil {
+			return nil, err
+		}
+		inviteID, _ := res.LastInsertId()
+
+		err = services.Notify(ctx, tx, models.Notification{
+			UserID:      userID,
+			Type:        "group_invite",
+			Message:     fmt.Sprintf("%s tracked your share of its expenses as a guest. Accept the invitation to join and take them over", g.name),
+			GroupID:     sql.NullInt64{Int64: int64(g.id), Valid: true},
+			ReferenceID: sql.NullInt64{Int64: inviteID, Valid: true},
+		})
+		if err != nil {
+			return nil, err
+		}
+		invited = append(invited, g.id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return invited, nil
+}
+
+// normaliseGuestEmail lowercases an optional guest email and checks it parses
+func normaliseGuestEmail(email *string) (sql.NullString, error) {
+	if email == nil || strings.TrimSpace(*email) == "" {
+		return sql.NullString{}, nil
+	}
+	addr := strings.ToLower(strings.TrimSpace(*email))
+	if _, err := mail.ParseAddress(addr); err != nil {
+		return sql.NullString{}, fmt.Errorf("invalid email address")
+	}
+	return sql.NullString{String: addr, Valid: true}, nil
+}
+
+// guestEmailTaken reports why email cannot be given to a guest of the group, or
+// "" when it can. excludeGuestID skips the guest being edited or restored.
+func guestEmailTaken(ctx context.Context, tx *sql.Tx, groupID, excludeGuestID int, email string) (string, error) {
+	var member bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM group_members gm JOIN users u ON u.id = gm.user_id WHERE gm.group_id = ? AND u.email = ?)
+	`, groupID, email).Scan(&member)
+	if err != nil {
+		return "", err
+	}
+	if member {
+		return "someone with this email is already a member of this group", nil
+	}
+
+	var guest bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_guests WHERE group_id = ? AND email = ? AND id != ?)",
+		groupID, email, excludeGuestID).Scan(&guest)
+	if err != nil {
+		return "", err
+	}
+	if guest {
+		return "a guest with this email already exists in this group", nil
+	}
+	return "", nil
+}
+
+// FUNC TO LIST THE GUESTS OF A GROUP
+func GetGroupGuestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	query := guestQuery + " WHERE g.group_id = ? AND g.removed_at IS NULL"
+	switch r.URL.Query().Get("status") {
+	case "", "active":
+		query += " AND g.merged_into_user_id IS NULL"
+	case "merged":
+		query += " AND g.merged_into_user_id IS NOT NULL"
+	case "all":
+	default:
+		utils.WriteError(w, "status must be active, merged or all", http.StatusBadRequest)
+		return
+	}
+	query += " ORDER BY g.display_name, g.id"
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, query, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to list guests: %v", err)
+		utils.WriteError(w, "failed to retrieve guests", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	guests := make([]models.GroupGuest, 0)
+	for rows.Next() {
+		guest, err := scanGuest(rows)
+		if err != nil {
+			utils.WriteError(w, "error reading guests", http.StatusInternalServerError)
+			return
+		}
+		guests = append(guests, *guest)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(guests),
+		"data":   guests,
+	})
+}
+
+// FUNC TO ADD A GUEST WHO HAS NO ACCOUNT YET TO A GROUP
+func CreateGroupGuestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		DisplayName string  `json:"display_name"`
+		Email       *string `json:"email"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req.DisplayName = strings.TrimSpace(req.DisplayName)
+	if req.DisplayName == "" || len(req.DisplayName) > 100 {
+		utils.WriteError(w, "display_name is required and must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	email, err := normaliseGuestEmail(req.Email)
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionInvite) {
+		return
+	}
+
+	archived, err := groupIsArchived(ctx, tx, groupID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if archived {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	var guestID int64
+	if email.Valid {
+		// a removed guest with the same email comes back with their history
+		var removedID int64
+		err = tx.QueryRowContext(ctx, "SELECT id FROM group_guests WHERE group_id = ? AND email = ? AND removed_at IS NOT NULL AND merged_into_user_id IS NULL", groupID, email.String).Scan(&removedID)
+		if err != nil && err != sql.ErrNoRows {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		reason, err := guestEmailTaken(ctx, tx, groupID, int(removedID), email.String)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if reason != "" {
+			utils.WriteError(w, reason, http.StatusConflict)
+			return
+		}
+
+		if removedID != 0 {
+			_, err = tx.ExecContext(ctx, "UPDATE group_guests SET display_name = ?, removed_at = NULL WHERE id = ?", req.DisplayName, removedID)
+			if err != nil {
+				utils.Logger.Errorf("failed to restore guest: %v", err)
+				utils.WriteError(w, "failed to add guest", http.StatusInternalServerError)
+				return
+			}
+			guestID = removedID
+		}
+	}
+
+	if guestID == 0 {
+		res, err := tx.ExecContext(ctx, "INSERT INTO group_guests (group_id, display_name, email, created_by) VALUES (?, ?, ?, ?)",
+			groupID, req.DisplayName, email, userID)
+		if err != nil {
+			utils.Logger.Errorf("failed to create guest: %v", err)
+			utils.WriteError(w, "failed to add guest", http.StatusInternalServerError)
+			return
+		}
+		guestID, _ = res.LastInsertId()
+	}
+
+	guest, err := scanGuest(tx.QueryRowContext(ctx, guestQuery+" WHERE g.id = ?", guestID))
+	if err != nil {
+		utils.WriteError(w, "failed to add guest", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to add guest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "guest added, they will be included in new expenses",
+		"data":    guest,
+	})
+}
+
+// FUNC TO RENAME A GUEST OR CHANGE THEIR EMAIL
+func UpdateGroupGuestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	guestID, err := strconv.Atoi(r.PathValue("guestId"))
+	if err != nil {
+		utils.WriteError(w, "invalid guest ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		DisplayName *string `json:"display_name"`
+		Email       *string `json:"email"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.DisplayName == nil && req.Email == nil {
+		utils.WriteError(w, "nothing to update", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionInvite) {
+		return
+	}
+
+	guest, err := scanGuest(tx.QueryRowContext(ctx, guestQuery+" WHERE g.id = ? AND g.group_id = ? AND g.removed_at IS NULL FOR UPDATE", guestID, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "guest not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if guest.MergedIntoUserID.Valid {
+		utils.WriteError(w, "guest has already been merged into a member", http.StatusConflict)
+		return
+	}
+
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if name == "" || len(name) > 100 {
+			utils.WriteError(w, "display_name is required and must be at most 100 characters", http.StatusBadRequest)
+			return
+		}
+		guest.DisplayName = name
+	}
+
+	if req.Email != nil {
+		email, err := normaliseGuestEmail(req.Email)
+		if err != nil {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if email.Valid && email != guest.Email {
+			reason, err := guestEmailTaken(ctx, tx, groupID, guestID, email.String)
+			if err != nil {
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			if reason != "" {
+				utils.WriteError(w, reason, http.StatusConflict)
+				return
+			}
+		}
+		guest.Email = email
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_guests SET display_name = ?, email = ? WHERE id = ?", guest.DisplayName, guest.Email, guestID)
+	if err != nil {
+		utils.Logger.Errorf("failed to update guest: %v", err)
+		utils.WriteError(w, "failed to update guest", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to update guest", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "guest updated",
+		"data":    guest,
+	})
+}
+
+// FUNC TO STOP INCLUDING A GUEST IN NEW EXPENSES
+func RemoveGroupGuestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	guestID, err := strconv.Atoi(r.PathValue("guestId"))
+	if err != nil {
+		utils.WriteError(w, "invalid guest ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionInvite) {
+		return
+	}
+
+	guest, err := scanGuest(tx.QueryRowContext(ctx, guestQuery+" WHERE g.id = ? AND g.group_id = ? AND g.removed_at IS NULL FOR UPDATE", guestID, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "guest not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if guest.MergedIntoUserID.Valid {
+		utils.WriteError(w, "guest has already been merged into a member", http.StatusConflict)
+		return
+	}
+
+	// the splits stay as history, so a guest can only go once they owe nothing
+	if guest.Outstanding.GreaterThan(decimal.Zero) {
+		utils.WriteError(w, fmt.Sprintf("guest still owes %s, settle their splits before removing them", guest.Outstanding.StringFixed(2)), http.StatusConflict)
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_guests SET removed_at = NOW() WHERE id = ?", guestID)
+	if err != nil {
+		utils.Logger.Errorf("failed to remove guest: %v", err)
+		utils.WriteError(w, "failed to remove guest", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to remove guest", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "guest removed",
+	})
+}
+
+type guestSplit struct {
+	SplitID     int             `json:"split_id"`
+	ExpenseID   int             `json:"expense_id"`
+	Description string          `json:"description"`
+	PaidBy      int             `json:"paid_by"`
+	ShareAmount decimal.Decimal `json:"share_amount"`
+	AmountOwed  decimal.Decimal `json:"amount_owed"`
+	IsSettled   bool            `json:"is_settled"`
+	CreatedAt   sql.NullString  `json:"created_at"`
+}
+
+// FUNC TO LIST THE SPLITS A GUEST OWES
+func GetGuestSplitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	guestID, err := strconv.Atoi(r.PathValue("guestId"))
+	if err != nil {
+		utils.WriteError(w, "invalid guest ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	guest, err := scanGuest(db.QueryRowContext(ctx, guestQuery+" WHERE g.id = ? AND g.group_id = ?", guestID, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "guest not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT s.id, e.id, e.description, e.paid_by, s.share_amount, s.amount_owed, s.is_settled, e.created_at
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.guest_id = ?
+		ORDER BY e.created_at DESC, s.id DESC
+	`, guestID)
+	if err != nil {
+		utils.Logger.Errorf("failed to list guest splits: %v", err)
+		utils.WriteError(w, "failed to retrieve guest splits", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	splits := make([]guestSplit, 0)
+	for rows.Next() {
+		var s guestSplit
+		if err := rows.Scan(&s.SplitID, &s.ExpenseID, &s.Description, &s.PaidBy, &s.ShareAmount, &s.AmountOwed, &s.IsSettled, &s.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading guest splits", http.StatusInternalServerError)
+			return
+		}
+		splits = append(splits, s)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"guest":  guest,
+			"splits": splits,
+		},
+	})
+}
+
+// FUNC TO RECORD MONEY A GUEST PAID BACK OUTSIDE THE APP
+func SettleGuestSplitHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	guestID, err := strconv.Atoi(r.PathValue("guestId"))
+	if err != nil {
+		utils.WriteError(w, "invalid guest ID", http.StatusBadRequest)
+		return
+	}
+
+	splitID, err := strconv.Atoi(r.PathValue("splitId"))
+	if err != nil {
+		utils.WriteError(w, "invalid split ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Amount *decimal.Decimal `json:"amount"`
+		Note   string           `json:"note"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Amount != nil {
+		if req.Amount.LessThanOrEqual(decimal.Zero) {
+			utils.WriteError(w, "amount must be greater than 0", http.StatusBadRequest)
+			return
+		}
+		if !utils.HasAtMostTwoDecimals(*req.Amount) {
+			utils.WriteError(w, "amount cannot have more than 2 decimal places", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(req.Note) > 255 {
+		utils.WriteError(w, "note must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var paidBy int
+	var amountOwed decimal.Decimal
+	err = tx.QueryRowContext(ctx, `
+		SELECT e.paid_by, s.amount_owed
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.id = ? AND s.guest_id = ? AND s.owed_by IS NULL AND e.group_id = ? AND s.is_settled = FALSE
+		FOR UPDATE
+	`, splitID, guestID, groupID).Scan(&paidBy, &amountOwed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "unsettled guest split not found", http.StatusNotFound)
+			return
+		}
+		utils.Logger.Errorf("error retrieving guest split: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// the money goes to whoever paid for the expense, so only they or an admin can confirm it
+	if paidBy != userID && !authorizeGroupAction(ctx, w, tx, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	amount := amountOwed
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.GreaterThan(amountOwed) {
+		utils.WriteError(w, fmt.Sprintf("amount exceeds the %s the guest owes on this split", amountOwed.StringFixed(2)), http.StatusBadRequest)
+		return
+	}
+
+	var note sql.NullString
+	if req.Note != "" {
+		note = sql.NullString{String: req.Note, Valid: true}
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO guest_split_payments (split_id, guest_id, amount, recorded_by, note) VALUES (?, ?, ?, ?, ?)",
+		splitID, guestID, amount, userID, note)
+	if err != nil {
+		utils.Logger.Errorf("failed to record guest payment: %v", err)
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+	paymentID, _ := res.LastInsertId()
+
+	remaining := amountOwed.Sub(amount)
+	_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ?, is_settled = ? WHERE id = ?", remaining, remaining.IsZero(), splitID)
+	if err != nil {
+		utils.Logger.Errorf("failed to update guest split: %v", err)
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	message := "guest payment recorded"
+	if remaining.IsZero() {
+		message = "guest split fully settled"
+	}
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": message,
+		"data": models.GuestSplitPayment{
+			ID:         int(paymentID),
+			SplitID:    splitID,
+			GuestID:    guestID,
+			Amount:     amount,
+			RecordedBy: userID,
+			Note:       note,
+		},
+		"remaining": remaining,
+	})
+}
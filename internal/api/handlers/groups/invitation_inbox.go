@@ -0,0 +1,225 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+type inboxInvitation struct {
+	ID               int            `json:"id"`
+	GroupID          int            `json:"group_id"`
+	GroupName        string         `json:"group_name"`
+	GroupDescription sql.NullString `json:"group_description"`
+	InvitedBy        int            `json:"invited_by"`
+	InviterName      string         `json:"inviter_name"`
+	ExpiresAt        sql.NullString `json:"expires_at"`
+	CreatedAt        sql.NullString `json:"created_at"`
+}
+
+// FUNC TO LIST PENDING GROUP INVITATIONS SENT TO THE LOGGED-IN USER
+func GetMyInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT gi.id, gi.group_id, g.name, g.description, gi.invited_by, CONCAT(u.first_name, ' ', u.last_name), gi.expires_at, gi.created_at
+		FROM group_invitations gi
+		JOIN users me ON me.id = ? AND me.email = gi.email
+		JOIN groups g ON g.id = gi.group_id
+		JOIN users u ON u.id = gi.invited_by
+		WHERE gi.status = 'pending' AND gi.expires_at > ? AND g.archived_at IS NULL
+		ORDER BY gi.created_at DESC, gi.id DESC
+	`, userID, time.Now().UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		utils.Logger.Errorf("failed to list invitations for user %d: %v", userID, err)
+		utils.WriteError(w, "failed to retrieve invitations", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	invitations := make([]inboxInvitation, 0)
+	for rows.Next() {
+		var inv inboxInvitation
+		if err := rows.Scan(&inv.ID, &inv.GroupID, &inv.GroupName, &inv.GroupDescription, &inv.InvitedBy, &inv.InviterName, &inv.ExpiresAt, &inv.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading invitations", http.StatusInternalServerError)
+			return
+		}
+		invitations = append(invitations, inv)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(invitations),
+		"data":   invitations,
+	})
+}
+
+// FUNC TO ACCEPT A GROUP INVITATION FROM THE INBOX
+func AcceptMyInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	respondToInvitation(w, r, "accepted")
+}
+
+// FUNC TO DECLINE A GROUP INVITATION FROM THE INBOX
+func DeclineMyInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	respondToInvitation(w, r, "declined")
+}
+
+func respondToInvitation(w http.ResponseWriter, r *http.Request, decision string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	inviteID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid invitation ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// only invitations addressed to the caller's email are visible to them
+	var groupID, invitedBy int
+	var email, status string
+	var expired bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT gi.group_id, gi.email, gi.status, gi.invited_by, gi.expires_at <= ?
+		FROM group_invitations gi
+		JOIN users me ON me.id = ? AND me.email = gi.email
+		WHERE gi.id = ? FOR UPDATE
+	`, time.Now().UTC().Format("2006-01-02 15:04:05"), userID, inviteID).Scan(&groupID, &email, &status, &invitedBy, &expired)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "invitation not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if status != "pending" {
+		utils.WriteError(w, "invitation already "+status, http.StatusConflict)
+		return
+	}
+	if expired {
+		utils.WriteError(w, "invitation has expired", http.StatusBadRequest)
+		return
+	}
+
+	if decision == "accepted" {
+		archived, err := groupIsArchived(ctx, tx, groupID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if archived {
+			utils.WriteError(w, "group is archived", http.StatusConflict)
+			return
+		}
+
+		if err := redeemInvitation(ctx, tx, inviteID, groupID, userID); err != nil {
+			if err == errAlreadyMember {
+				utils.WriteError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			utils.Logger.Errorf("failed to join group: %v", err)
+			utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE group_invitations SET status = 'declined', responded_at = NOW() WHERE id = ?", inviteID)
+		if err != nil {
+			utils.Logger.Errorf("failed to decline invitation: %v", err)
+			utils.WriteError(w, "failed to decline invitation", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if decision == "declined" {
+		go notifyInviteDeclined(invitedBy, groupID, email)
+	}
+
+	message := "invite accepted successfully"
+	if decision == "declined" {
+		message = "invite declined"
+	}
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": message,
+		"data": map[string]interface{}{
+			"invite_id": inviteID,
+			"group_id":  groupID,
+			"status":    decision,
+		},
+	})
+}
+
+// notifyInviteDeclined emails the inviter that their invitation was turned down
+func notifyInviteDeclined(inviterID, groupID int, inviteeEmail string) {
+	db := sqlconnect.DB
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var to, firstName, groupName string
+	err := db.QueryRowContext(ctx, `
+		SELECT u.email, u.first_name, g.name FROM users u JOIN groups g ON g.id = ? WHERE u.id = ?
+	`, groupID, inviterID).Scan(&to, &firstName, &groupName)
+	if err != nil {
+		utils.Logger.Errorf("failed to load inviter %d for declined invite: %v", inviterID, err)
+		return
+	}
+
+	if err := utils.SendInviteDeclinedEmail(to, firstName, inviteeEmail, groupName); err != nil {
+		utils.Logger.Errorf("failed to send invite declined email to %s: %v", to, err)
+	}
+}
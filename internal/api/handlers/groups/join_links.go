@@ -0,0 +1,855 @@
+package groups
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/qrcode"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+const joinLinkColumns = "id, group_id, code, created_by, max_uses, uses, expires_at, requires_approval, status, revoked_at, created_at"
+
+func scanJoinLink(row interface{ Scan(...interface{}) error }) (*models.GroupJoinLink, error) {
+	var link models.GroupJoinLink
+	err := row.Scan(&link.ID, &link.GroupID, &link.Code, &link.CreatedBy, &link.MaxUses, &link.Uses,
+		&link.ExpiresAt, &link.RequiresApproval, &link.Status, &link.RevokedAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	link.URL = joinLinkURL(link.Code)
+	return &link, nil
+}
+
+func joinLinkURL(code string) string {
+	return fmt.Sprintf("https://localhost:3000/groups/join/%s", code)
+}
+
+// newJoinCode returns a random code short enough to type from a screen
+func newJoinCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// insertJoinLink creates an active link with a fresh code and returns it
+func insertJoinLink(ctx context.Context, tx *sql.Tx, groupID, userID int, maxUses sql.NullInt64, expiresAt sql.NullString, requiresApproval bool) (*models.GroupJoinLink, error) {
+	code, err := newJoinCode()
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO group_join_links (group_id, code, created_by, max_uses, expires_at, requires_approval)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, groupID, code, userID, maxUses, expiresAt, requiresApproval)
+	if err != nil {
+		return nil, err
+	}
+	linkID, _ := res.LastInsertId()
+	return scanJoinLink(tx.QueryRowContext(ctx, "SELECT "+joinLinkColumns+" FROM group_join_links WHERE id = ?", linkID))
+}
+
+// FUNC TO CREATE A SHAREABLE JOIN LINK FOR A GROUP
+func CreateJoinLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		MaxUses          *int `json:"max_uses"`
+		ExpiresInHours   *int `json:"expires_in_hours"`
+		RequiresApproval bool `json:"requires_approval"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	var maxUses sql.NullInt64
+	if req.MaxUses != nil {
+		if *req.MaxUses <= 0 {
+			utils.WriteError(w, "max_uses must be greater than 0", http.StatusBadRequest)
+			return
+		}
+		maxUses = sql.NullInt64{Int64: int64(*req.MaxUses), Valid: true}
+	}
+
+	var expiresAt sql.NullString
+	if req.ExpiresInHours != nil {
+		if *req.ExpiresInHours <= 0 {
+			utils.WriteError(w, "expires_in_hours must be greater than 0", http.StatusBadRequest)
+			return
+		}
+		expiry := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		expiresAt = sql.NullString{String: expiry.Format("2006-01-02 15:04:05"), Valid: true}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	group, ok := loadGroupForAdmin(ctx, w, db, groupID, userID)
+	if !ok {
+		return
+	}
+	if group.ArchivedAt.Valid {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	link, err := insertJoinLink(ctx, tx, groupID, userID, maxUses, expiresAt, req.RequiresApproval)
+	if err != nil {
+		utils.Logger.Errorf("failed to create join link: %v", err)
+		utils.WriteError(w, "failed to create join link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to create join link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "join link created",
+		"data":    link,
+	})
+}
+
+// FUNC TO LIST THE JOIN LINKS OF A GROUP
+func GetJoinLinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	query := "SELECT " + joinLinkColumns + " FROM group_join_links WHERE group_id = ?"
+	args := []interface{}{groupID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != "active" && status != "revoked" && status != "rotated" {
+			utils.WriteError(w, "status must be active, revoked or rotated", http.StatusBadRequest)
+			return
+		}
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve join links", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	links := make([]*models.GroupJoinLink, 0)
+	for rows.Next() {
+		link, err := scanJoinLink(rows)
+		if err != nil {
+			utils.WriteError(w, "error reading join links", http.StatusInternalServerError)
+			return
+		}
+		links = append(links, link)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(links),
+		"data":   links,
+	})
+}
+
+// loadActiveJoinLink locks the group's link for update, writing the error
+// response itself when it does not exist or is no longer active
+func loadActiveJoinLink(ctx context.Context, w http.ResponseWriter, tx *sql.Tx, groupID, linkID int) (*models.GroupJoinLink, bool) {
+	link, err := scanJoinLink(tx.QueryRowContext(ctx, "SELECT "+joinLinkColumns+" FROM group_join_links WHERE id = ? AND group_id = ? FOR UPDATE", linkID, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "join link not found", http.StatusNotFound)
+			return nil, false
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return nil, false
+	}
+	if link.Status != "active" {
+		utils.WriteError(w, "join link has been "+link.Status, http.StatusConflict)
+		return nil, false
+	}
+	return link, true
+}
+
+// FUNC TO REPLACE A JOIN LINK WITH A NEW CODE, KEEPING ITS SETTINGS
+func RotateJoinLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	linkID, err := strconv.Atoi(r.PathValue("linkId"))
+	if err != nil {
+		utils.WriteError(w, "invalid join link ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	group, ok := loadGroupForAdmin(ctx, w, db, groupID, userID)
+	if !ok {
+		return
+	}
+	if group.ArchivedAt.Valid {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	old, ok := loadActiveJoinLink(ctx, w, tx, groupID, linkID)
+	if !ok {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_join_links SET status = 'rotated', revoked_at = NOW() WHERE id = ?", linkID)
+	if err != nil {
+		utils.Logger.Errorf("failed to retire join link: %v", err)
+		utils.WriteError(w, "failed to rotate join link", http.StatusInternalServerError)
+		return
+	}
+
+	// the new code starts with a fresh use count but keeps the old limits
+	link, err := insertJoinLink(ctx, tx, groupID, userID, old.MaxUses, old.ExpiresAt, old.RequiresApproval)
+	if err != nil {
+		utils.Logger.Errorf("failed to create join link: %v", err)
+		utils.WriteError(w, "failed to rotate join link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to rotate join link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":       "success",
+		"message":      "join link rotated, the old code no longer works",
+		"rotated_from": linkID,
+		"data":         link,
+	})
+}
+
+// FUNC TO REVOKE A JOIN LINK
+func RevokeJoinLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	linkID, err := strconv.Atoi(r.PathValue("linkId"))
+	if err != nil {
+		utils.WriteError(w, "invalid join link ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, ok := loadActiveJoinLink(ctx, w, tx, groupID, linkID); !ok {
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_join_links SET status = 'revoked', revoked_at = NOW() WHERE id = ?", linkID)
+	if err != nil {
+		utils.Logger.Errorf("failed to revoke join link: %v", err)
+		utils.WriteError(w, "failed to revoke join link", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to revoke join link", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "join link revoked",
+	})
+}
+
+// FUNC TO RENDER A JOIN LINK AS A QR CODE
+func GetJoinLinkQRHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	linkID, err := strconv.Atoi(r.PathValue("linkId"))
+	if err != nil {
+		utils.WriteError(w, "invalid join link ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		utils.WriteError(w, "format must be png or svg", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	link, err := scanJoinLink(db.QueryRowContext(ctx, "SELECT "+joinLinkColumns+" FROM group_join_links WHERE id = ? AND group_id = ?", linkID, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "join link not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if link.Status != "active" {
+		utils.WriteError(w, "join link has been "+link.Status, http.StatusConflict)
+		return
+	}
+
+	code, err := qrcode.Encode(link.URL)
+	if err != nil {
+		utils.Logger.Errorf("failed to encode join link QR code: %v", err)
+		utils.WriteError(w, "failed to generate QR code", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "svg" {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(code.SVG()))
+		return
+	}
+
+	img, err := code.PNG(8)
+	if err != nil {
+		utils.Logger.Errorf("failed to render join link QR code: %v", err)
+		utils.WriteError(w, "failed to generate QR code", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(img)
+}
+
+// FUNC TO JOIN A GROUP THROUGH A JOIN LINK
+func JoinGroupByLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	code := r.PathValue("code")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	link, err := scanJoinLink(tx.QueryRowContext(ctx, "SELECT "+joinLinkColumns+" FROM group_join_links WHERE code = ? FOR UPDATE", code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "join link is invalid", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if link.Status != "active" {
+		utils.WriteError(w, "join link is no longer valid", http.StatusGone)
+		return
+	}
+	if link.ExpiresAt.Valid && link.ExpiresAt.String <= time.Now().Format("2006-01-02 15:04:05") {
+		utils.WriteError(w, "join link has expired", http.StatusGone)
+		return
+	}
+	if link.MaxUses.Valid && int64(link.Uses) >= link.MaxUses.Int64 {
+		utils.WriteError(w, "join link has reached its maximum number of uses", http.StatusGone)
+		return
+	}
+
+	archived, err := groupIsArchived(ctx, tx, link.GroupID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if archived {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	role, err := memberRole(ctx, tx, link.GroupID, userID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if role != "" {
+		utils.WriteError(w, errAlreadyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// a use is counted when the link is redeemed, whether or not an admin
+	// still has to approve, so max_uses also caps the approval queue
+	_, err = tx.ExecContext(ctx, "UPDATE group_join_links SET uses = uses + 1 WHERE id = ?", link.ID)
+	if err != nil {
+		utils.Logger.Errorf("failed to count join link use: %v", err)
+		utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+		return
+	}
+
+	if link.RequiresApproval {
+		var pending bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_join_requests WHERE group_id = ? AND user_id = ? AND status = 'pending')", link.GroupID, userID).Scan(&pending)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if pending {
+			utils.WriteError(w, "you already have a pending request to join this group", http.StatusConflict)
+			return
+		}
+
+		res, err := tx.ExecContext(ctx, "INSERT INTO group_join_requests (group_id, user_id, link_id) VALUES (?, ?, ?)", link.GroupID, userID, link.ID)
+		if err != nil {
+			utils.Logger.Errorf("failed to create join request: %v", err)
+			utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+			return
+		}
+		requestID, _ := res.LastInsertId()
+
+		err = services.NotifyGroupAdmins(ctx, tx, link.GroupID, "join_request", "Someone used a join link and is waiting for approval",
+			sql.NullInt64{Int64: requestID, Valid: true})
+		if err != nil {
+			utils.Logger.Errorf("failed to notify admins of join request: %v", err)
+			utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":  "success",
+			"message": "request to join sent, an admin has to approve it",
+			"data": map[string]interface{}{
+				"request_id": requestID,
+				"group_id":   link.GroupID,
+			},
+		})
+		return
+	}
+
+	if err := addGroupMember(ctx, tx, link.GroupID, userID); err != nil {
+		if err == errAlreadyMember {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.Logger.Errorf("failed to join group: %v", err)
+		utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to join group", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "joined group successfully",
+		"data": map[string]interface{}{
+			"group_id": link.GroupID,
+		},
+	})
+}
+
+// FUNC TO LIST REQUESTS TO JOIN A GROUP
+func GetJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "approved" && status != "rejected" {
+		utils.WriteError(w, "status must be pending, approved or rejected", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT jr.id, jr.group_id, jr.user_id, u.username, u.email, jr.link_id, jr.source, jr.status, jr.decided_by, jr.decided_at, jr.created_at
+		FROM group_join_requests jr
+		JOIN users u ON u.id = jr.user_id
+		WHERE jr.group_id = ? AND jr.status = ?
+		ORDER BY jr.created_at, jr.id
+	`, groupID, status)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve join requests", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	requests := make([]models.GroupJoinRequest, 0)
+	for rows.Next() {
+		var jr models.GroupJoinRequest
+		if err := rows.Scan(&jr.ID, &jr.GroupID, &jr.UserID, &jr.Username, &jr.Email, &jr.LinkID, &jr.Source, &jr.Status, &jr.DecidedBy, &jr.DecidedAt, &jr.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading join requests", http.StatusInternalServerError)
+			return
+		}
+		requests = append(requests, jr)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(requests),
+		"data":   requests,
+	})
+}
+
+// FUNC TO APPROVE A REQUEST TO JOIN A GROUP
+func ApproveJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	decideJoinRequest(w, r, "approved")
+}
+
+// FUNC TO REJECT A REQUEST TO JOIN A GROUP
+func RejectJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	decideJoinRequest(w, r, "rejected")
+}
+
+func decideJoinRequest(w http.ResponseWriter, r *http.Request, decision string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	requestID, err := strconv.Atoi(r.PathValue("requestId"))
+	if err != nil {
+		utils.WriteError(w, "invalid join request ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if !authorizeGroupAction(ctx, w, tx, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	var jr models.GroupJoinRequest
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, group_id, user_id, status FROM group_join_requests WHERE id = ? AND group_id = ? FOR UPDATE
+	`, requestID, groupID).Scan(&jr.ID, &jr.GroupID, &jr.UserID, &jr.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "join request not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if jr.Status != "pending" {
+		utils.WriteError(w, "join request has already been "+jr.Status, http.StatusConflict)
+		return
+	}
+
+	if decision == "approved" {
+		archived, err := groupIsArchived(ctx, tx, groupID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if archived {
+			utils.WriteError(w, "group is archived", http.StatusConflict)
+			return
+		}
+
+		// someone who joined another way in the meantime is simply left as they are
+		if err := addGroupMember(ctx, tx, groupID, jr.UserID); err != nil && err != errAlreadyMember {
+			utils.Logger.Errorf("failed to add member from join request: %v", err)
+			utils.WriteError(w, "failed to approve join request", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_join_requests SET status = ?, decided_by = ?, decided_at = ? WHERE id = ?
+	`, decision, userID, time.Now().Format("2006-01-02 15:04:05"), requestID)
+	if err != nil {
+		utils.Logger.Errorf("failed to update join request: %v", err)
+		utils.WriteError(w, "failed to update join request", http.StatusInternalServerError)
+		return
+	}
+
+	var groupName string
+	if err := tx.QueryRowContext(ctx, "SELECT name FROM groups WHERE id = ?", groupID).Scan(&groupName); err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	err = services.Notify(ctx, tx, models.Notification{
+		UserID:      jr.UserID,
+		Type:        "join_request_" + decision,
+		Message:     fmt.Sprintf("Your request to join %s was %s", groupName, decision),
+		GroupID:     sql.NullInt64{Int64: int64(groupID), Valid: true},
+		ReferenceID: sql.NullInt64{Int64: int64(requestID), Valid: true},
+	})
+	if err != nil {
+		utils.Logger.Errorf("failed to notify user of join request decision: %v", err)
+		utils.WriteError(w, "failed to update join request", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to update join request", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "join request " + decision,
+		"data": map[string]interface{}{
+			"request_id": requestID,
+			"group_id":   groupID,
+			"user_id":    jr.UserID,
+			"status":     decision,
+		},
+	})
+}
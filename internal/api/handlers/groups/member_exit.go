@@ -0,0 +1,58 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+)
+
+// writeOutstandingDebts refuses a membership change and lists what is still owed
+func writeOutstandingDebts(w http.ResponseWriter, message string, debts []services.OutstandingDebt) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":            "error",
+		"message":           message,
+		"outstanding_debts": debts,
+	})
+}
+
+// resolveDebtsBeforeExit settles or writes off memberID's debts in the group
+// inside tx. On failure, or if anything is left unresolved, it rolls tx back,
+// writes the response and returns false.
+func resolveDebtsBeforeExit(ctx context.Context, w http.ResponseWriter, tx *sql.Tx, groupID, memberID, actorID int, settleUp, writeOff bool, reason string) (*services.DebtResolution, bool) {
+	resolution, err := services.ResolveMemberDebts(ctx, tx, groupID, memberID, actorID, settleUp, writeOff, reason)
+	if err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to resolve debts of user %d in group %d: %v", memberID, groupID, err)
+		handlers.WriteSettlementError(w, err)
+		return nil, false
+	}
+
+	if len(resolution.Unresolved) > 0 {
+		tx.Rollback()
+		writeOutstandingDebts(w, "outstanding debts must be settled (settle_up) or written off (write_off) first", resolution.Unresolved)
+		return nil, false
+	}
+
+	return resolution, true
+}
+
+// notifyExitSettlements emails the creditors paid while a member left
+func notifyExitSettlements(ctx context.Context, db *sql.DB, payerID int, resolution *services.DebtResolution) {
+	for _, settlement := range resolution.Settled {
+		services.NotifySplitPayment(ctx, db, payerID, settlement)
+	}
+}
+
+func exitSummary(resolution *services.DebtResolution) map[string]interface{} {
+	return map[string]interface{}{
+		"offset":      resolution.Offset,
+		"settled":     len(resolution.Settled),
+		"written_off": resolution.WrittenOff,
+	}
+}
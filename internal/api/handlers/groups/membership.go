@@ -0,0 +1,197 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+var errAlreadyMember = errors.New("you are already a member of this group")
+
+// addGroupMember adds the user to the group as a regular member and merges any
+// guest added under their email into them. Every way of joining a group,
+// invitation, join link or join request, goes through here.
+func addGroupMember(ctx context.Context, tx *sql.Tx, groupID, userID int) error {
+	res, err := tx.ExecContext(ctx, `INSERT IGNORE INTO group_members (group_id, user_id, role) VALUES (?, ?, 'member')`, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errAlreadyMember
+	}
+	return mergeGuests(ctx, tx, groupID, userID)
+}
+
+// redeemInvitation marks the invitation accepted, keeping it as history, and
+// adds the user to its group
+func redeemInvitation(ctx context.Context, tx *sql.Tx, inviteID, groupID, userID int) error {
+	_, err := tx.ExecContext(ctx, "UPDATE group_invitations SET status = 'accepted', responded_at = NOW() WHERE id = ?", inviteID)
+	if err != nil {
+		return err
+	}
+	return addGroupMember(ctx, tx, groupID, userID)
+}
+
+// sendInviteEmail sends the join link to registered users and a signup
+// invitation to addresses that have no account yet
+func sendInviteEmail(email string, registered bool, group models.Group, token string, expiresAt time.Time) {
+	go func() {
+		time.AfterFunc(500*time.Millisecond, func() {
+			var err error
+			if registered {
+				link := fmt.Sprintf("https://localhost:3000/groups/invite/%s", token)
+				err = utils.SendGroupInviteEmail(email, group.Name, group.Description, link, expiresAt)
+			} else {
+				link := fmt.Sprintf("https://localhost:3000/signup?email=%s", url.QueryEscape(email))
+				err = utils.SendGroupSignupInviteEmail(email, group.Name, group.Description, link, expiresAt)
+			}
+			if err != nil {
+				utils.Logger.Errorf("failed to send invite email to %s: %v", email, err)
+			}
+		})
+	}()
+}
+
+// AcceptPendingInvites adds a newly verified user to every group that has a
+// pending, unexpired invitation for their email and returns the groups joined.
+// Invitations to archived groups are left pending.
+func AcceptPendingInvites(ctx context.Context, db *sql.DB, userID int, email string) ([]int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT gi.id, gi.group_id
+		FROM group_invitations gi
+		JOIN groups g ON g.id = gi.group_id
+		WHERE gi.email = ? AND gi.status = 'pending' AND gi.expires_at > ? AND g.archived_at IS NULL
+		FOR UPDATE
+	`, email, time.Now().UTC().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+
+	var invites []models.GroupInvitation
+	for rows.Next() {
+		var invite models.GroupInvitation
+		if err := rows.Scan(&invite.ID, &invite.GroupID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		invites = append(invites, invite)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	joined := make([]int, 0, len(invites))
+	for _, invite := range invites {
+		if err := redeemInvitation(ctx, tx, invite.ID, invite.GroupID, userID); err != nil {
+			if err == errAlreadyMember {
+				continue
+			}
+			return nil, err
+		}
+		joined = append(joined, invite.GroupID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return joined, nil
+}
+
+type membershipEvent struct {
+	Kind        string         `json:"kind"`
+	ID          int            `json:"id"`
+	Email       string         `json:"email"`
+	UserID      sql.NullInt64  `json:"user_id"`
+	Source      string         `json:"source"`
+	Status      string         `json:"status"`
+	ActorID     sql.NullInt64  `json:"actor_id"`
+	CreatedAt   sql.NullString `json:"created_at"`
+	RespondedAt sql.NullString `json:"responded_at"`
+}
+
+// FUNC TO LIST A GROUP'S INVITATIONS AND JOIN REQUESTS AS ONE HISTORY
+func GetMembershipHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	page, limit := utils.GetPaginationParams(r)
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForAdmin(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	// actor_id is the inviter for invitations and the deciding admin for join requests
+	rows, err := db.QueryContext(ctx, `
+		SELECT 'invitation', id, email, invited_user_id, channel, status, invited_by, created_at, responded_at
+		FROM group_invitations WHERE group_id = ?
+		UNION ALL
+		SELECT 'join_request', jr.id, u.email, jr.user_id, jr.source, jr.status, jr.decided_by, jr.created_at, jr.decided_at
+		FROM group_join_requests jr JOIN users u ON u.id = jr.user_id WHERE jr.group_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, groupID, groupID, limit, offset)
+	if err != nil {
+		utils.Logger.Errorf("failed to load membership history: %v", err)
+		utils.WriteError(w, "failed to retrieve membership history", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	events := make([]membershipEvent, 0)
+	for rows.Next() {
+		var e membershipEvent
+		if err := rows.Scan(&e.Kind, &e.ID, &e.Email, &e.UserID, &e.Source, &e.Status, &e.ActorID, &e.CreatedAt, &e.RespondedAt); err != nil {
+			utils.WriteError(w, "error reading membership history", http.StatusInternalServerError)
+			return
+		}
+		events = append(events, e)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":    "success",
+		"count":     len(events),
+		"page":      page,
+		"page_size": limit,
+		"data":      events,
+	})
+}
@@ -0,0 +1,463 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// groupAction is something a member may or may not do depending on their role
+// and the group's settings
+type groupAction int
+
+const (
+	actionManageGroup groupAction = iota
+	actionAddExpense
+	actionEditOthersExpense
+	actionInvite
+)
+
+type rowsQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadGroupSettings returns the group's settings, or the defaults when none have
+// been saved. Split weights are left out, see loadSplitWeights.
+func loadGroupSettings(ctx context.Context, q rowQueryer, groupID int) (*models.GroupSettings, error) {
+	settings := models.GroupSettings{
+		GroupID:             groupID,
+		AddExpensePolicy:    "members",
+		EditExpensePolicy:   "payer_only",
+		InvitePolicy:        "admins",
+		Currency:            "NGN",
+		ReminderFrequency:   "daily",
+		DefaultSplitWeights: []models.SplitWeight{},
+	}
+	err := q.QueryRowContext(ctx, `
+		SELECT add_expense_policy, edit_expense_policy, invite_policy, approval_threshold,
+			currency, default_category_id, allow_self_settle, reminder_frequency, updated_by, updated_at
+		FROM group_settings WHERE group_id = ?
+	`, groupID).Scan(&settings.AddExpensePolicy, &settings.EditExpensePolicy, &settings.InvitePolicy,
+		&settings.ApprovalThreshold, &settings.Currency, &settings.DefaultCategoryID, &settings.AllowSelfSettle,
+		&settings.ReminderFrequency, &settings.UpdatedBy, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// loadSplitWeights returns the default split weights of the group's current
+// members. Members without a weight split with weight 1.
+func loadSplitWeights(ctx context.Context, q rowsQueryer, groupID int) ([]models.SplitWeight, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT w.user_id, w.weight
+		FROM group_split_weights w
+		JOIN group_members gm ON gm.group_id = w.group_id AND gm.user_id = w.user_id
+		WHERE w.group_id = ?
+		ORDER BY w.user_id
+	`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	weights := make([]models.SplitWeight, 0)
+	for rows.Next() {
+		var sw models.SplitWeight
+		if err := rows.Scan(&sw.UserID, &sw.Weight); err != nil {
+			return nil, err
+		}
+		weights = append(weights, sw)
+	}
+	return weights, rows.Err()
+}
+
+// validCurrencyCode reports whether code looks like an ISO 4217 currency code
+func validCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// memberRole returns the user's role in the group, or "" when they are not a member
+func memberRole(ctx context.Context, q rowQueryer, groupID, userID int) (string, error) {
+	var role string
+	err := q.QueryRowContext(ctx, "SELECT role FROM group_members WHERE group_id = ? AND user_id = ?", groupID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// authorizeGroupAction checks the user may perform action in the group under
+// its settings, writing the error response itself when they may not
+func authorizeGroupAction(ctx context.Context, w http.ResponseWriter, q rowQueryer, groupID, userID int, action groupAction) bool {
+	role, err := memberRole(ctx, q, groupID, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to check group role: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return false
+	}
+	if role == "" {
+		utils.WriteError(w, "you are not a member of this group", http.StatusForbidden)
+		return false
+	}
+	// payer_only binds admins too, so only that action needs the settings for them
+	if role == "admin" && action != actionEditOthersExpense {
+		return true
+	}
+
+	if action == actionManageGroup {
+		utils.WriteError(w, "forbidden: not group admin", http.StatusForbidden)
+		return false
+	}
+
+	settings, err := loadGroupSettings(ctx, q, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to load group settings: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return false
+	}
+
+	switch action {
+	case actionAddExpense:
+		if settings.AddExpensePolicy == "members" {
+			return true
+		}
+		utils.WriteError(w, "forbidden: only admins can add expenses in this group", http.StatusForbidden)
+	case actionEditOthersExpense:
+		switch {
+		case settings.EditExpensePolicy == "members":
+			return true
+		case settings.EditExpensePolicy == "admins" && role == "admin":
+			return true
+		case settings.EditExpensePolicy == "admins":
+			utils.WriteError(w, "forbidden: only the payer or an admin can change this expense", http.StatusForbidden)
+		default:
+			utils.WriteError(w, "forbidden: you can only change expenses you paid for", http.StatusForbidden)
+		}
+	case actionInvite:
+		if settings.InvitePolicy == "members" {
+			return true
+		}
+		utils.WriteError(w, "forbidden: only admins can invite members to this group", http.StatusForbidden)
+	}
+	return false
+}
+
+// FUNC TO GET OR UPDATE THE SETTINGS OF A GROUP
+func GroupSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPatch {
+		UpdateGroupSettingsHandler(w, r)
+		return
+	}
+	GetGroupSettingsHandler(w, r)
+}
+
+// FUNC TO GET THE SETTINGS OF A GROUP
+func GetGroupSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	settings, err := loadGroupSettings(ctx, db, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve group settings", http.StatusInternalServerError)
+		return
+	}
+
+	settings.DefaultSplitWeights, err = loadSplitWeights(ctx, db, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve group settings", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"data":   settings,
+	})
+}
+
+// FUNC TO UPDATE THE SETTINGS OF A GROUP
+func UpdateGroupSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	// approval_threshold and default_category_id are json.RawMessage so that null
+	// can clear them. default_split_weights replaces every weight, [] clears them.
+	type request struct {
+		AddExpensePolicy    *string               `json:"add_expense_policy"`
+		EditExpensePolicy   *string               `json:"edit_expense_policy"`
+		InvitePolicy        *string               `json:"invite_policy"`
+		ApprovalThreshold   json.RawMessage       `json:"approval_threshold"`
+		Currency            *string               `json:"currency"`
+		DefaultCategoryID   json.RawMessage       `json:"default_category_id"`
+		AllowSelfSettle     *bool                 `json:"allow_self_settle"`
+		ReminderFrequency   *string               `json:"reminder_frequency"`
+		DefaultSplitWeights *[]models.SplitWeight `json:"default_split_weights"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, ok := loadGroupForMember(ctx, w, db, groupID, userID); !ok {
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	settings, err := loadGroupSettings(ctx, db, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve group settings", http.StatusInternalServerError)
+		return
+	}
+
+	policies := []struct {
+		name    string
+		value   *string
+		target  *string
+		allowed []string
+	}{
+		{"add_expense_policy", req.AddExpensePolicy, &settings.AddExpensePolicy, []string{"members", "admins"}},
+		{"edit_expense_policy", req.EditExpensePolicy, &settings.EditExpensePolicy, []string{"payer_only", "admins", "members"}},
+		{"invite_policy", req.InvitePolicy, &settings.InvitePolicy, []string{"admins", "members"}},
+		{"reminder_frequency", req.ReminderFrequency, &settings.ReminderFrequency, []string{"off", "daily", "weekly", "monthly"}},
+	}
+	for _, p := range policies {
+		if p.value == nil {
+			continue
+		}
+		valid := false
+		for _, a := range p.allowed {
+			if *p.value == a {
+				valid = true
+			}
+		}
+		if !valid {
+			utils.WriteError(w, "invalid "+p.name, http.StatusBadRequest)
+			return
+		}
+		*p.target = *p.value
+	}
+
+	if len(req.ApprovalThreshold) > 0 {
+		var threshold decimal.NullDecimal
+		if err := json.Unmarshal(req.ApprovalThreshold, &threshold); err != nil {
+			utils.WriteError(w, "invalid approval_threshold", http.StatusBadRequest)
+			return
+		}
+		if threshold.Valid && threshold.Decimal.LessThanOrEqual(decimal.Zero) {
+			utils.WriteError(w, "approval_threshold must be greater than 0, or null to turn approvals off", http.StatusBadRequest)
+			return
+		}
+		settings.ApprovalThreshold = threshold
+	}
+
+	currencyChanged := false
+	if req.Currency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*req.Currency))
+		if !validCurrencyCode(currency) {
+			utils.WriteError(w, "currency must be a 3 letter ISO 4217 code", http.StatusBadRequest)
+			return
+		}
+		currencyChanged = currency != settings.Currency
+		settings.Currency = currency
+	}
+
+	if len(req.DefaultCategoryID) > 0 {
+		var categoryID sql.NullInt64
+		if err := json.Unmarshal(req.DefaultCategoryID, &categoryID); err != nil {
+			utils.WriteError(w, "invalid default_category_id", http.StatusBadRequest)
+			return
+		}
+		if categoryID.Valid {
+			valid, err := validCategory(ctx, db, groupID, int(categoryID.Int64))
+			if err != nil {
+				utils.WriteError(w, "failed to verify category", http.StatusInternalServerError)
+				return
+			}
+			if !valid {
+				utils.WriteError(w, "category not found in this group", http.StatusBadRequest)
+				return
+			}
+		}
+		settings.DefaultCategoryID = categoryID
+	}
+
+	if req.AllowSelfSettle != nil {
+		settings.AllowSelfSettle = *req.AllowSelfSettle
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// splits carry no currency of their own, so changing it would re-denominate every
+	// debt. The group row is locked because adding an expense updates it too.
+	if currencyChanged {
+		var hasExpenses bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS(SELECT 1 FROM group_expenses WHERE group_id = g.id) FROM groups g WHERE g.id = ? FOR UPDATE
+		`, groupID).Scan(&hasExpenses)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if hasExpenses {
+			utils.WriteError(w, "the currency cannot be changed once the group has expenses", http.StatusConflict)
+			return
+		}
+	}
+
+	if req.DefaultSplitWeights != nil {
+		seen := make(map[int]bool)
+		for _, sw := range *req.DefaultSplitWeights {
+			if sw.Weight.LessThanOrEqual(decimal.Zero) || !utils.HasAtMostTwoDecimals(sw.Weight) || sw.Weight.GreaterThanOrEqual(decimal.NewFromInt(1000000)) {
+				utils.WriteError(w, "each weight must be greater than 0, below 1000000 and have at most 2 decimal places", http.StatusBadRequest)
+				return
+			}
+			if seen[sw.UserID] {
+				utils.WriteError(w, fmt.Sprintf("user %d has more than one weight", sw.UserID), http.StatusBadRequest)
+				return
+			}
+			seen[sw.UserID] = true
+
+			role, err := memberRole(ctx, tx, groupID, sw.UserID)
+			if err != nil {
+				utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+				return
+			}
+			if role == "" {
+				utils.WriteError(w, fmt.Sprintf("user %d is not a member of this group", sw.UserID), http.StatusBadRequest)
+				return
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM group_split_weights WHERE group_id = ?", groupID); err != nil {
+			utils.Logger.Errorf("failed to clear split weights: %v", err)
+			utils.WriteError(w, "failed to update group settings", http.StatusInternalServerError)
+			return
+		}
+		for _, sw := range *req.DefaultSplitWeights {
+			_, err := tx.ExecContext(ctx, "INSERT INTO group_split_weights (group_id, user_id, weight) VALUES (?, ?, ?)", groupID, sw.UserID, sw.Weight)
+			if err != nil {
+				utils.Logger.Errorf("failed to save split weight: %v", err)
+				utils.WriteError(w, "failed to update group settings", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO group_settings (group_id, add_expense_policy, edit_expense_policy, invite_policy, approval_threshold,
+			currency, default_category_id, allow_self_settle, reminder_frequency, updated_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE add_expense_policy = VALUES(add_expense_policy), edit_expense_policy = VALUES(edit_expense_policy),
+			invite_policy = VALUES(invite_policy), approval_threshold = VALUES(approval_threshold), currency = VALUES(currency),
+			default_category_id = VALUES(default_category_id), allow_self_settle = VALUES(allow_self_settle),
+			reminder_frequency = VALUES(reminder_frequency), updated_by = VALUES(updated_by)
+	`, groupID, settings.AddExpensePolicy, settings.EditExpensePolicy, settings.InvitePolicy, settings.ApprovalThreshold,
+		settings.Currency, settings.DefaultCategoryID, settings.AllowSelfSettle, settings.ReminderFrequency, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to save group settings: %v", err)
+		utils.WriteError(w, "failed to update group settings", http.StatusInternalServerError)
+		return
+	}
+	settings.UpdatedBy = sql.NullInt64{Int64: int64(userID), Valid: true}
+
+	settings.DefaultSplitWeights, err = loadSplitWeights(ctx, tx, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to update group settings", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to update group settings", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "group settings updated",
+		"data":    settings,
+	})
+}
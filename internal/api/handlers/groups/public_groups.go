@@ -0,0 +1,199 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type publicGroup struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Description sql.NullString `json:"description"`
+	Members     int            `json:"members"`
+	IsMember    bool           `json:"is_member"`
+	CreatedAt   sql.NullString `json:"created_at"`
+}
+
+// FUNC TO LIST PUBLIC GROUPS ANYONE CAN ASK TO JOIN
+func GetPublicGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	page, limit := utils.GetPaginationParams(r)
+	offset := (page - 1) * limit
+
+	query := `
+		SELECT g.id, g.name, g.description, COUNT(gm.id),
+			EXISTS(SELECT 1 FROM group_members me WHERE me.group_id = g.id AND me.user_id = ?), g.created_at
+		FROM groups g
+		LEFT JOIN group_members gm ON gm.group_id = g.id
+		WHERE g.is_public = TRUE AND g.archived_at IS NULL`
+	args := []interface{}{userID}
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		query += " AND g.name LIKE ?"
+		args = append(args, "%"+q+"%")
+	}
+	query += " GROUP BY g.id ORDER BY g.name, g.id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		utils.Logger.Errorf("failed to list public groups: %v", err)
+		utils.WriteError(w, "failed to retrieve groups", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	groups := make([]publicGroup, 0)
+	for rows.Next() {
+		var g publicGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.Members, &g.IsMember, &g.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading groups", http.StatusInternalServerError)
+			return
+		}
+		groups = append(groups, g)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":    "success",
+		"count":     len(groups),
+		"page":      page,
+		"page_size": limit,
+		"data":      groups,
+	})
+}
+
+// FUNC TO ASK TO JOIN A PUBLIC GROUP
+func RequestToJoinGroupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// private groups answer as if they did not exist
+	var name string
+	var isPublic bool
+	var archivedAt sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT name, is_public, archived_at FROM groups WHERE id = ?", groupID).Scan(&name, &isPublic, &archivedAt)
+	if err != nil || !isPublic {
+		if err == nil || err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if archivedAt.Valid {
+		utils.WriteError(w, "group is archived", http.StatusConflict)
+		return
+	}
+
+	role, err := memberRole(ctx, tx, groupID, userID)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if role != "" {
+		utils.WriteError(w, errAlreadyMember.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var pending bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_join_requests WHERE group_id = ? AND user_id = ? AND status = 'pending')", groupID, userID).Scan(&pending)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if pending {
+		utils.WriteError(w, "you already have a pending request to join this group", http.StatusConflict)
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO group_join_requests (group_id, user_id, source) VALUES (?, ?, 'public')", groupID, userID)
+	if err != nil {
+		utils.Logger.Errorf("failed to create join request: %v", err)
+		utils.WriteError(w, "failed to send join request", http.StatusInternalServerError)
+		return
+	}
+	requestID, _ := res.LastInsertId()
+
+	err = services.NotifyGroupAdmins(ctx, tx, groupID, "join_request", "Someone asked to join "+name,
+		sql.NullInt64{Int64: requestID, Valid: true})
+	if err != nil {
+		utils.Logger.Errorf("failed to notify admins of join request: %v", err)
+		utils.WriteError(w, "failed to send join request", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to send join request", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "request to join sent, an admin has to approve it",
+		"data": map[string]interface{}{
+			"request_id": requestID,
+			"group_id":   groupID,
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package groups
+
+import "qiyana_paybuddy/pkg/queryspec"
+
+var groupSpec = queryspec.Spec{
+	Filters: map[string]queryspec.Field{
+		"name":          {Column: "name", Ops: []queryspec.Operator{queryspec.Eq, queryspec.Like}},
+		"description":   {Column: "description", Ops: []queryspec.Operator{queryspec.Eq, queryspec.Like}},
+		"total_expense": {Column: "total_expense", Kind: queryspec.Number, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+		"created_at":    {Column: "created_at", Kind: queryspec.Date, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+	},
+	Aliases: map[string]queryspec.Alias{
+		"name":          {Field: "name", Op: queryspec.Eq},
+		"description":   {Field: "description", Op: queryspec.Eq},
+		"total_expense": {Field: "total_expense", Op: queryspec.Eq},
+	},
+	Sorts:       map[string]string{"name": "name", "total_expense": "total_expense", "created_at": "created_at"},
+	DefaultSort: []queryspec.SortTerm{{Column: "created_at", Desc: true}},
+	TieBreaker:  "id",
+	Search:      []string{"name", "description"},
+}
+
+var expenseSpec = queryspec.Spec{
+	Filters: map[string]queryspec.Field{
+		"paid_by":     {Column: "e.paid_by", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}},
+		"category_id": {Column: "e.category_id", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}},
+		"amount":      {Column: "e.amount", Kind: queryspec.Number, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+		"description": {Column: "e.description", Ops: []queryspec.Operator{queryspec.Eq, queryspec.Like}},
+		"created_at":  {Column: "e.created_at", Kind: queryspec.Date, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+	},
+	Aliases: map[string]queryspec.Alias{
+		"amount":      {Field: "amount", Op: queryspec.Eq},
+		"description": {Field: "description", Op: queryspec.Eq},
+	},
+	Sorts:       map[string]string{"amount": "e.amount", "description": "e.description", "created_at": "e.created_at"},
+	DefaultSort: []queryspec.SortTerm{{Column: "e.created_at", Desc: true}},
+	TieBreaker:  "e.id",
+	Search:      []string{"e.description"},
+}
+
+var invitationSpec = queryspec.Spec{
+	Filters: map[string]queryspec.Field{
+		"email":      {Column: "email", Ops: []queryspec.Operator{queryspec.Eq, queryspec.Like}},
+		"invited_by": {Column: "invited_by", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq}},
+		"expires_at": {Column: "expires_at", Kind: queryspec.Date, Ops: []queryspec.Operator{queryspec.Gte, queryspec.Lte}},
+	},
+	Sorts:       map[string]string{"email": "email", "expires_at": "expires_at", "created_at": "created_at"},
+	DefaultSort: []queryspec.SortTerm{{Column: "created_at", Desc: true}},
+	TieBreaker:  "id",
+	Search:      []string{"email"},
+}
@@ -0,0 +1,254 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// lastAdmin reports whether the user is the only admin left in the group. Inside
+// a transaction the admin rows stay locked, so two admins cannot step down at once.
+func lastAdmin(ctx context.Context, q rowQueryer, groupID, userID int) (bool, error) {
+	var others int
+	err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM group_members WHERE group_id = ? AND role = 'admin' AND user_id <> ? FOR UPDATE
+	`, groupID, userID).Scan(&others)
+	return others == 0, err
+}
+
+// FUNC TO PROMOTE A MEMBER TO GROUP ADMIN
+func PromoteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	changeMemberRole(w, r, "admin")
+}
+
+// FUNC TO DEMOTE A GROUP ADMIN TO MEMBER
+func DemoteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	changeMemberRole(w, r, "member")
+}
+
+func changeMemberRole(w http.ResponseWriter, r *http.Request, role string) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	memberID, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil {
+		utils.WriteError(w, "invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var ownerID int
+	err = db.QueryRowContext(ctx, "SELECT created_by FROM groups WHERE id = ?", groupID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if !authorizeGroupAction(ctx, w, db, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx, "SELECT role FROM group_members WHERE group_id = ? AND user_id = ? FOR UPDATE", groupID, memberID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "user is not a member of this group", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if current == role {
+		utils.WriteError(w, "member already has the "+role+" role", http.StatusConflict)
+		return
+	}
+
+	if role == "member" {
+		if memberID == ownerID {
+			utils.WriteError(w, "the group owner cannot be demoted; transfer ownership first", http.StatusConflict)
+			return
+		}
+
+		last, err := lastAdmin(ctx, tx, groupID, memberID)
+		if err != nil {
+			utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if last {
+			utils.WriteError(w, "a group must always have at least one admin", http.StatusConflict)
+			return
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_members SET role = ? WHERE group_id = ? AND user_id = ?", role, groupID, memberID)
+	if err != nil {
+		utils.Logger.Errorf("failed to change member role: %v", err)
+		utils.WriteError(w, "failed to change member role", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to change member role", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "member role updated",
+		"data": map[string]interface{}{
+			"group_id": groupID,
+			"user_id":  memberID,
+			"role":     role,
+		},
+	})
+}
+
+// FUNC TO HAND OWNERSHIP OF A GROUP TO ANOTHER MEMBER
+func TransferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	groupID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		UserID int `json:"user_id"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.UserID == 0 || req.UserID == userID {
+		utils.WriteError(w, "user_id must be another member of the group", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var ownerID int
+	err = tx.QueryRowContext(ctx, "SELECT created_by FROM groups WHERE id = ? FOR UPDATE", groupID).Scan(&ownerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "group not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if ownerID != userID {
+		utils.WriteError(w, "forbidden: only the group owner can transfer ownership", http.StatusForbidden)
+		return
+	}
+
+	// the new owner is made an admin and the old owner stays one
+	res, err := tx.ExecContext(ctx, "UPDATE group_members SET role = 'admin' WHERE group_id = ? AND user_id = ?", groupID, req.UserID)
+	if err != nil {
+		utils.Logger.Errorf("failed to promote new owner: %v", err)
+		utils.WriteError(w, "failed to transfer ownership", http.StatusInternalServerError)
+		return
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		var exists bool
+		tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?)", groupID, req.UserID).Scan(&exists)
+		if !exists {
+			utils.WriteError(w, "user is not a member of this group", http.StatusNotFound)
+			return
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE groups SET created_by = ? WHERE id = ?", req.UserID, groupID)
+	if err != nil {
+		utils.Logger.Errorf("failed to transfer ownership: %v", err)
+		utils.WriteError(w, "failed to transfer ownership", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to transfer ownership", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "group ownership transferred",
+		"data": map[string]interface{}{
+			"group_id": groupID,
+			"owner_id": req.UserID,
+		},
+	})
+}
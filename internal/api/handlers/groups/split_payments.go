@@ -0,0 +1,422 @@
+package groups
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FUNC TO LIST PAYMENTS MADE TOWARDS AN EXPENSE SPLIT
+func GetSplitPaymentsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idStr := r.PathValue("split_id")
+	splitID, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.WriteError(w, "invalid split ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var owedBy, paidBy int
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(s.owed_by, 0), e.paid_by FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.id = ?
+	`, splitID).Scan(&owedBy, &paidBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "expense split not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if owedBy != userID && paidBy != userID {
+		utils.WriteError(w, "you are not a party to this expense split", http.StatusForbidden)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, split_id, payer_id, payee_id, amount, debit_transaction_id, credit_transaction_id,
+			status, refund_debit_transaction_id, refund_credit_transaction_id, refunded_at, created_at
+		FROM split_payments WHERE split_id = ?
+		ORDER BY created_at DESC
+	`, splitID)
+	if err != nil {
+		utils.Logger.Errorf("failed to retrieve split payments: %v", err)
+		utils.WriteError(w, "failed to retrieve split payments", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	payments := make([]models.SplitPayment, 0)
+	for rows.Next() {
+		var p models.SplitPayment
+		if err := rows.Scan(&p.ID, &p.SplitID, &p.PayerID, &p.PayeeID, &p.Amount, &p.DebitTransactionID, &p.CreditTransactionID,
+			&p.Status, &p.RefundDebitTransactionID, &p.RefundCreditTransactionID, &p.RefundedAt, &p.CreatedAt); err != nil {
+			utils.Logger.Errorf("error scanning split payment: %v", err)
+			utils.WriteError(w, "error reading split payments", http.StatusInternalServerError)
+			return
+		}
+		payments = append(payments, p)
+	}
+
+	offlineRows, err := db.QueryContext(ctx, `
+		SELECT id, split_id, payer_id, payee_id, amount, recorded_by, note, created_at
+		FROM offline_split_payments WHERE split_id = ?
+		ORDER BY created_at DESC
+	`, splitID)
+	if err != nil {
+		utils.Logger.Errorf("failed to retrieve offline split payments: %v", err)
+		utils.WriteError(w, "failed to retrieve split payments", http.StatusInternalServerError)
+		return
+	}
+	defer offlineRows.Close()
+
+	offline := make([]models.OfflineSplitPayment, 0)
+	for offlineRows.Next() {
+		var p models.OfflineSplitPayment
+		if err := offlineRows.Scan(&p.ID, &p.SplitID, &p.PayerID, &p.PayeeID, &p.Amount, &p.RecordedBy, &p.Note, &p.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading split payments", http.StatusInternalServerError)
+			return
+		}
+		offline = append(offline, p)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":           "success",
+		"count":            len(payments),
+		"data":             payments,
+		"offline_payments": offline,
+	})
+}
+
+// FUNC FOR THE CREDITOR TO REFUND A SPLIT PAYMENT AND REOPEN THE DEBT
+func RefundSplitPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idStr := r.PathValue("split_id")
+	splitID, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.WriteError(w, "invalid split ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		PaymentID int    `json:"payment_id"`
+		Pin       string `json:"pin"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.PaymentID <= 0 {
+		utils.WriteError(w, "payment_id is required", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	var payment models.SplitPayment
+	err = db.QueryRowContext(ctx, "SELECT split_id, payee_id, status FROM split_payments WHERE id = ?", req.PaymentID).
+		Scan(&payment.SplitID, &payment.PayeeID, &payment.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "split payment not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if payment.SplitID != splitID {
+		utils.WriteError(w, "payment does not belong to this split", http.StatusBadRequest)
+		return
+	}
+
+	if payment.PayeeID != userID {
+		utils.WriteError(w, "only the member who received this payment can refund it", http.StatusForbidden)
+		return
+	}
+
+	if payment.Status == "refunded" {
+		utils.WriteError(w, "split payment already refunded", http.StatusConflict)
+		return
+	}
+
+	if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+		handlers.WritePinError(w, err)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Error("error starting transaction")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	refund, err := services.RefundSplitPayment(ctx, tx, req.PaymentID, userID)
+	if err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to refund split payment %d: %v", req.PaymentID, err)
+		handlers.WriteSettlementError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.Logger.Errorf("transaction commit failed: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	services.NotifySplitRefund(ctx, db, refund)
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "split payment refunded and debt reopened",
+		"data": map[string]interface{}{
+			"payment_id":       refund.PaymentID,
+			"split_id":         refund.SplitID,
+			"amount_refunded":  refund.Amount,
+			"amount_owed":      refund.AmountOwed,
+			"debit_reference":  refund.Transfer.DebitReference,
+			"credit_reference": refund.Transfer.CreditReference,
+		},
+	})
+}
+
+// FUNC TO RECORD A SPLIT SETTLED OUTSIDE THE WALLET, WHERE THE GROUP ALLOWS IT
+func RecordOfflineSplitPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	splitID, err := strconv.Atoi(r.PathValue("split_id"))
+	if err != nil {
+		utils.WriteError(w, "invalid split ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Amount *decimal.Decimal `json:"amount"`
+		Note   string           `json:"note"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if req.Amount != nil {
+		if req.Amount.LessThanOrEqual(decimal.Zero) {
+			utils.WriteError(w, "amount must be greater than 0", http.StatusBadRequest)
+			return
+		}
+		if !utils.HasAtMostTwoDecimals(*req.Amount) {
+			utils.WriteError(w, "amount cannot have more than 2 decimal places", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(req.Note) > 255 {
+		utils.WriteError(w, "note must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var owedBy, paidBy, groupID int
+	var amountOwed decimal.Decimal
+	err = tx.QueryRowContext(ctx, `
+		SELECT s.owed_by, e.paid_by, e.group_id, s.amount_owed
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.id = ? AND s.owed_by IS NOT NULL AND s.is_settled = FALSE
+		FOR UPDATE
+	`, splitID).Scan(&owedBy, &paidBy, &groupID, &amountOwed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "expense split not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// the payee is the one who knows the money arrived, so the debtor cannot vouch for it
+	if owedBy == userID {
+		utils.WriteError(w, "only the member who is owed or a group admin can record this payment", http.StatusForbidden)
+		return
+	}
+	if paidBy != userID && !authorizeGroupAction(ctx, w, tx, groupID, userID, actionManageGroup) {
+		return
+	}
+
+	settings, err := loadGroupSettings(ctx, tx, groupID)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve group settings", http.StatusInternalServerError)
+		return
+	}
+	if !settings.AllowSelfSettle {
+		utils.WriteError(w, "this group only allows settling splits through the wallet", http.StatusForbidden)
+		return
+	}
+
+	amount := amountOwed
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.GreaterThan(amountOwed) {
+		utils.WriteError(w, fmt.Sprintf("amount exceeds the %s owed on this split", amountOwed.StringFixed(2)), http.StatusBadRequest)
+		return
+	}
+
+	var note sql.NullString
+	if req.Note != "" {
+		note = sql.NullString{String: req.Note, Valid: true}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO offline_split_payments (split_id, payer_id, payee_id, amount, recorded_by, note)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, splitID, owedBy, paidBy, amount, userID, note)
+	if err != nil {
+		utils.Logger.Errorf("failed to record offline split payment: %v", err)
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+	paymentID, _ := res.LastInsertId()
+
+	remaining := amountOwed.Sub(amount)
+	_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ?, is_settled = ? WHERE id = ?", remaining, remaining.IsZero(), splitID)
+	if err != nil {
+		utils.Logger.Errorf("failed to update split: %v", err)
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	// whichever party did not record this hears about it
+	for _, party := range []int{owedBy, paidBy} {
+		if party == userID {
+			continue
+		}
+		err = services.Notify(ctx, tx, models.Notification{
+			UserID:      party,
+			Type:        "offline_split_payment",
+			Message:     fmt.Sprintf("A payment of %s %s on split #%d was recorded as settled outside the wallet", settings.Currency, amount.StringFixed(2), splitID),
+			GroupID:     sql.NullInt64{Int64: int64(groupID), Valid: true},
+			ReferenceID: sql.NullInt64{Int64: paymentID, Valid: true},
+		})
+		if err != nil {
+			utils.Logger.Errorf("failed to notify offline split payment: %v", err)
+			utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to record payment", http.StatusInternalServerError)
+		return
+	}
+
+	message := "payment outside the wallet recorded"
+	if remaining.IsZero() {
+		message = "split settled outside the wallet"
+	}
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": message,
+		"data": models.OfflineSplitPayment{
+			ID:         int(paymentID),
+			SplitID:    splitID,
+			PayerID:    owedBy,
+			PayeeID:    paidBy,
+			Amount:     amount,
+			RecordedBy: userID,
+			Note:       note,
+		},
+		"currency":  settings.Currency,
+		"remaining": remaining,
+	})
+}
@@ -0,0 +1,170 @@
+package notifications
+
+import (
+	"context"
+	"net/http"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+// FUNC TO LIST THE LOGGED-IN USER'S NOTIFICATIONS
+func GetMyNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	query := "SELECT id, user_id, type, message, group_id, reference_id, read_at, created_at FROM notifications WHERE user_id = ?"
+	switch r.URL.Query().Get("status") {
+	case "", "all":
+	case "unread":
+		query += " AND read_at IS NULL"
+	default:
+		utils.WriteError(w, "status must be unread or all", http.StatusBadRequest)
+		return
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?"
+
+	page, limit := utils.GetPaginationParams(r)
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, "failed to retrieve notifications", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	notifications := make([]models.Notification, 0)
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Message, &n.GroupID, &n.ReferenceID, &n.ReadAt, &n.CreatedAt); err != nil {
+			utils.WriteError(w, "error reading notifications", http.StatusInternalServerError)
+			return
+		}
+		notifications = append(notifications, n)
+	}
+
+	var unread int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&unread); err != nil {
+		utils.WriteError(w, "failed to count unread notifications", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":    "success",
+		"count":     len(notifications),
+		"unread":    unread,
+		"page":      page,
+		"page_size": limit,
+		"data":      notifications,
+	})
+}
+
+// FUNC TO MARK ONE NOTIFICATION AS READ
+func MarkNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	notificationID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		utils.WriteError(w, "invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)", notificationID, userID).Scan(&exists)
+	if err != nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		utils.WriteError(w, "notification not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = db.ExecContext(ctx, "UPDATE notifications SET read_at = NOW() WHERE id = ? AND read_at IS NULL", notificationID)
+	if err != nil {
+		utils.WriteError(w, "failed to update notification", http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "notification marked as read",
+	})
+}
+
+// FUNC TO MARK ALL OF THE LOGGED-IN USER'S NOTIFICATIONS AS READ
+func MarkAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	res, err := db.ExecContext(ctx, "UPDATE notifications SET read_at = NOW() WHERE user_id = ? AND read_at IS NULL", userID)
+	if err != nil {
+		utils.WriteError(w, "failed to update notifications", http.StatusInternalServerError)
+		return
+	}
+	updated, _ := res.RowsAffected()
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "notifications marked as read",
+		"updated": updated,
+	})
+}
@@ -0,0 +1,479 @@
+package requests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FUNC TO REQUEST MONEY FROM ONE OR MORE USERS
+func CreateMoneyRequestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		RecipientIDs []int           `json:"recipient_ids"`
+		GroupID      *int            `json:"group_id"`
+		Amount       decimal.Decimal `json:"amount"`
+		Reason       string          `json:"reason"`
+		DueDate      string          `json:"due_date"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		utils.WriteError(w, "reason is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Reason) > 255 {
+		utils.WriteError(w, "reason must be at most 255 characters", http.StatusBadRequest)
+		return
+	}
+
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		utils.WriteError(w, "amount must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	if !utils.HasAtMostTwoDecimals(req.Amount) {
+		utils.WriteError(w, "amount cannot have more than 2 decimal places", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.RecipientIDs) == 0 {
+		utils.WriteError(w, "at least one recipient is required", http.StatusBadRequest)
+		return
+	}
+
+	var dueDate sql.NullString
+	if req.DueDate != "" {
+		if _, err := time.Parse("2006-01-02", req.DueDate); err != nil {
+			utils.WriteError(w, "due_date must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+		if req.DueDate < time.Now().Format("2006-01-02") {
+			utils.WriteError(w, "due_date cannot be in the past", http.StatusBadRequest)
+			return
+		}
+		dueDate = sql.NullString{String: req.DueDate, Valid: true}
+	}
+
+	seen := map[int]bool{}
+	var recipientIDs []int
+	for _, id := range req.RecipientIDs {
+		if id == userID {
+			utils.WriteError(w, "you cannot request money from yourself", http.StatusBadRequest)
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			recipientIDs = append(recipientIDs, id)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var groupID sql.NullInt64
+	if req.GroupID != nil {
+		groupID = sql.NullInt64{Int64: int64(*req.GroupID), Valid: true}
+		for _, id := range append([]int{userID}, recipientIDs...) {
+			var isMember bool
+			err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = ? AND user_id = ?)", *req.GroupID, id).Scan(&isMember)
+			if err != nil {
+				utils.WriteError(w, "failed to verify group membership", http.StatusInternalServerError)
+				return
+			}
+			if !isMember {
+				utils.WriteError(w, fmt.Sprintf("user %d is not a member of this group", id), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	var requesterName string
+	if err := db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", userID).Scan(&requesterName); err != nil {
+		utils.WriteError(w, "user not found", http.StatusNotFound)
+		return
+	}
+
+	type recipient struct {
+		id        int
+		email     string
+		firstName string
+	}
+
+	var recipients []recipient
+	for _, id := range recipientIDs {
+		rc := recipient{id: id}
+		err := db.QueryRowContext(ctx, "SELECT email, first_name FROM users WHERE id = ?", id).Scan(&rc.email, &rc.firstName)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				utils.WriteError(w, fmt.Sprintf("user %d not found", id), http.StatusNotFound)
+				return
+			}
+			utils.WriteError(w, "failed to fetch recipients", http.StatusInternalServerError)
+			return
+		}
+		recipients = append(recipients, rc)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Errorf("failed to start transaction: %v", err)
+		utils.WriteError(w, "failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO money_requests (requester_id, recipient_id, group_id, amount, reason, due_date) VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to prepare statement: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	defer stmt.Close()
+
+	requestIDs := make([]int64, 0, len(recipients))
+	for _, rc := range recipients {
+		res, err := stmt.ExecContext(ctx, userID, rc.id, groupID, req.Amount, req.Reason, dueDate)
+		if err != nil {
+			tx.Rollback()
+			utils.Logger.Errorf("failed to create money request: %v", err)
+			utils.WriteError(w, "failed to create money request", http.StatusInternalServerError)
+			return
+		}
+		id, _ := res.LastInsertId()
+		requestIDs = append(requestIDs, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.WriteError(w, "failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	for _, rc := range recipients {
+		go func(rc recipient) {
+			if err := utils.SendMoneyRequestEmail(rc.email, rc.firstName, requesterName, req.Amount.StringFixed(2), req.Reason, req.DueDate, false); err != nil {
+				utils.Logger.Errorf("failed to send money request email to %s: %v", rc.email, err)
+			}
+		}(rc)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": fmt.Sprintf("money request sent to %d user(s)", len(recipients)),
+		"data": map[string]interface{}{
+			"request_ids": requestIDs,
+			"amount":      req.Amount,
+			"reason":      req.Reason,
+		},
+	})
+}
+
+// FUNC TO LIST MONEY REQUESTS SENT TO THE LOGGED-IN USER
+func GetMoneyRequestInboxHandler(w http.ResponseWriter, r *http.Request) {
+	listMoneyRequests(w, r, "recipient_id")
+}
+
+// FUNC TO LIST MONEY REQUESTS SENT BY THE LOGGED-IN USER
+func GetSentMoneyRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	listMoneyRequests(w, r, "requester_id")
+}
+
+func listMoneyRequests(w http.ResponseWriter, r *http.Request, ownerColumn string) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	// the inbox defaults to outstanding requests, the sent list to everything
+	status := r.URL.Query().Get("status")
+	if status == "" && ownerColumn == "recipient_id" {
+		status = "pending"
+	}
+	validStatuses := map[string]bool{"": true, "all": true, "pending": true, "paid": true, "declined": true, "cancelled": true}
+	if !validStatuses[status] {
+		utils.WriteError(w, "invalid status filter", http.StatusBadRequest)
+		return
+	}
+
+	page, limit := utils.GetPaginationParams(r)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	query := "FROM money_requests WHERE " + ownerColumn + " = ?"
+	args := []interface{}{userID}
+	if status != "" && status != "all" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) "+query, args...).Scan(&total); err != nil {
+		utils.Logger.Errorf("failed to count money requests: %v", err)
+		utils.WriteError(w, "failed to retrieve money requests", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, requester_id, recipient_id, group_id, amount, reason, due_date, status,
+			debit_transaction_id, credit_transaction_id, responded_at, created_at, updated_at
+		`+query+" ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		utils.Logger.Errorf("failed to retrieve money requests: %v", err)
+		utils.WriteError(w, "failed to retrieve money requests", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	moneyRequests := make([]models.MoneyRequest, 0)
+	for rows.Next() {
+		var m models.MoneyRequest
+		if err := rows.Scan(&m.ID, &m.RequesterID, &m.RecipientID, &m.GroupID, &m.Amount, &m.Reason, &m.DueDate, &m.Status,
+			&m.DebitTransactionID, &m.CreditTransactionID, &m.RespondedAt, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			utils.Logger.Errorf("error scanning money request: %v", err)
+			utils.WriteError(w, "error reading money requests", http.StatusInternalServerError)
+			return
+		}
+		moneyRequests = append(moneyRequests, m)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"count":  len(moneyRequests),
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+		"data":   moneyRequests,
+	})
+}
+
+// FUNC TO ACCEPT AND PAY A MONEY REQUEST FROM THE WALLET
+func AcceptMoneyRequestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	requestID, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.WriteError(w, "invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	type request struct {
+		Pin string `json:"pin"`
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		utils.WriteError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+
+	var recipientID int
+	err = db.QueryRowContext(ctx, "SELECT recipient_id FROM money_requests WHERE id = ?", requestID).Scan(&recipientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, "money request not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if recipientID != userID {
+		utils.WriteError(w, "this money request was not sent to you", http.StatusForbidden)
+		return
+	}
+
+	if err := handlers.VerifyTransactionPin(ctx, db, userID, req.Pin); err != nil {
+		handlers.WritePinError(w, err)
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Error("error starting transaction")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	payment, err := services.PayMoneyRequest(ctx, tx, requestID, userID)
+	if err != nil {
+		tx.Rollback()
+		utils.Logger.Errorf("failed to pay money request %d: %v", requestID, err)
+		handlers.WriteSettlementError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.Logger.Errorf("transaction commit failed: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	services.NotifyMoneyRequestResponse(ctx, db, requestID, "paid")
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "money request paid",
+		"data": map[string]interface{}{
+			"request_id":      payment.RequestID,
+			"amount_paid":     payment.Amount,
+			"wallet_balance":  payment.Transfer.PayerBalance,
+			"debit_reference": payment.Transfer.DebitReference,
+		},
+	})
+}
+
+// FUNC FOR THE RECIPIENT TO DECLINE A MONEY REQUEST
+func DeclineMoneyRequestHandler(w http.ResponseWriter, r *http.Request) {
+	closeMoneyRequest(w, r, "declined")
+}
+
+// FUNC FOR THE REQUESTER TO CANCEL A MONEY REQUEST
+func CancelMoneyRequestHandler(w http.ResponseWriter, r *http.Request) {
+	closeMoneyRequest(w, r, "cancelled")
+}
+
+func closeMoneyRequest(w http.ResponseWriter, r *http.Request, status string) {
+	if r.Method != http.MethodPost {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idStr := r.PathValue("id")
+	requestID, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.WriteError(w, "invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		utils.Logger.Error("error starting transaction")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := services.CloseMoneyRequest(ctx, tx, requestID, userID, status); err != nil {
+		tx.Rollback()
+		handlers.WriteSettlementError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.Logger.Errorf("transaction commit failed: %v", err)
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if status == "declined" {
+		services.NotifyMoneyRequestResponse(ctx, db, requestID, status)
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": fmt.Sprintf("money request %s", status),
+	})
+}
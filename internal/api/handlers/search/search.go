@@ -0,0 +1,262 @@
+package search
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/pkg/utils"
+	"strings"
+	"time"
+)
+
+// minTokenLength mirrors innodb_ft_min_token_size; shorter words are never indexed
+const minTokenLength = 3
+
+type searchResult struct {
+	Type      string         `json:"type"`
+	ID        int            `json:"id"`
+	Title     string         `json:"title"`
+	Subtitle  string         `json:"subtitle"`
+	GroupID   sql.NullInt64  `json:"group_id"`
+	CreatedAt sql.NullString `json:"created_at"`
+	Score     float64        `json:"score"`
+}
+
+// searchSource describes one searchable table. Scope is a FROM/JOIN/WHERE
+// fragment that limits rows to what the caller can see and takes the caller's
+// id ScopeArgs times.
+type searchSource struct {
+	Type      string
+	Columns   []string
+	Select    string
+	Scope     string
+	ScopeArgs int
+}
+
+var searchSources = []searchSource{
+	{
+		Type:    "expense",
+		Columns: []string{"e.description"},
+		Select:  "e.id, e.description, g.name, e.group_id, e.created_at",
+		Scope: `FROM group_expenses e
+			JOIN groups g ON g.id = e.group_id
+			JOIN group_members gm ON gm.group_id = e.group_id AND gm.user_id = ?
+			WHERE`,
+		ScopeArgs: 1,
+	},
+	{
+		Type:    "group",
+		Columns: []string{"g.name", "g.description"},
+		Select:  "g.id, g.name, COALESCE(g.description, ''), g.id, g.created_at",
+		Scope: `FROM groups g
+			JOIN group_members gm ON gm.group_id = g.id AND gm.user_id = ?
+			WHERE`,
+		ScopeArgs: 1,
+	},
+	{
+		Type:      "transaction",
+		Columns:   []string{"t.description", "t.reference"},
+		Select:    "t.id, COALESCE(t.description, t.reference), t.reference, t.group_id, t.created_at",
+		Scope:     `FROM transactions t WHERE t.user_id = ? AND`,
+		ScopeArgs: 1,
+	},
+	{
+		Type:    "member",
+		Columns: []string{"u.username"},
+		Select:  "u.id, u.username, CONCAT(u.first_name, ' ', u.last_name), NULL, u.user_created_at",
+		Scope: `FROM users u
+			WHERE u.id <> ? AND u.id IN (
+				SELECT mates.user_id FROM group_members mine
+				JOIN group_members mates ON mates.group_id = mine.group_id
+				WHERE mine.user_id = ?
+			) AND`,
+		ScopeArgs: 2,
+	},
+}
+
+// booleanQuery turns free text into a prefix-matching BOOLEAN MODE query,
+// dropping operator characters and words too short to be in the index
+func booleanQuery(q string) string {
+	clean := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, q)
+
+	var terms []string
+	for _, word := range strings.Fields(clean) {
+		if len([]rune(word)) >= minTokenLength {
+			terms = append(terms, word+"*")
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// buildSearchQuery unions every requested source into one ranked result set.
+// With fulltext the score is the MATCH relevance; otherwise prefix matches
+// rank above substring matches.
+func buildSearchQuery(sources []searchSource, userID int, q string, fulltext bool) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+
+	for _, src := range sources {
+		var score, where string
+		var scoreArgs, whereArgs []interface{}
+
+		if fulltext {
+			match := "MATCH(" + strings.Join(src.Columns, ", ") + ") AGAINST (? IN BOOLEAN MODE)"
+			score, where = match, match
+			scoreArgs = []interface{}{q}
+			whereArgs = []interface{}{q}
+		} else {
+			var prefix, contains []string
+			for _, col := range src.Columns {
+				prefix = append(prefix, col+" LIKE ?")
+				contains = append(contains, col+" LIKE ?")
+				scoreArgs = append(scoreArgs, escapeLike(q)+"%")
+				whereArgs = append(whereArgs, "%"+escapeLike(q)+"%")
+			}
+			score = "CASE WHEN " + strings.Join(prefix, " OR ") + " THEN 2 ELSE 1 END"
+			where = "(" + strings.Join(contains, " OR ") + ")"
+		}
+
+		parts = append(parts, "SELECT '"+src.Type+"' AS type, "+src.Select+", "+score+" AS score "+src.Scope+" "+where)
+		args = append(args, scoreArgs...)
+		for i := 0; i < src.ScopeArgs; i++ {
+			args = append(args, userID)
+		}
+		args = append(args, whereArgs...)
+	}
+
+	return strings.Join(parts, " UNION ALL "), args
+}
+
+// runSearch returns one page of results and the total match count
+func runSearch(ctx context.Context, db *sql.DB, union string, args []interface{}, limit, offset int) ([]searchResult, int, error) {
+	var total int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+union+") AS results", args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	pageArgs := append(append([]interface{}{}, args...), limit, offset)
+	rows, err := db.QueryContext(ctx, "SELECT * FROM ("+union+") AS results ORDER BY score DESC, created_at DESC, id DESC LIMIT ? OFFSET ?", pageArgs...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := make([]searchResult, 0)
+	for rows.Next() {
+		var res searchResult
+		if err := rows.Scan(&res.Type, &res.ID, &res.Title, &res.Subtitle, &res.GroupID, &res.CreatedAt, &res.Score); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, res)
+	}
+
+	return results, total, rows.Err()
+}
+
+// FUNC TO SEARCH EXPENSES, GROUPS, TRANSACTIONS AND MEMBERS VISIBLE TO THE LOGGED-IN USER
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		utils.WriteError(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	if len(q) > 100 {
+		utils.WriteError(w, "q must be at most 100 characters", http.StatusBadRequest)
+		return
+	}
+
+	sources := searchSources
+	if types := r.URL.Query().Get("type"); types != "" {
+		sources = nil
+		for _, t := range strings.Split(types, ",") {
+			found := false
+			for _, src := range searchSources {
+				if src.Type == strings.TrimSpace(t) {
+					sources = append(sources, src)
+					found = true
+					break
+				}
+			}
+			if !found {
+				utils.WriteError(w, "type must be one of expense, group, transaction, member", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	page, limit := utils.GetPaginationParams(r)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var results []searchResult
+	var total int
+	var err error
+
+	mode := "like"
+	if ftQuery := booleanQuery(q); ftQuery != "" {
+		union, args := buildSearchQuery(sources, userID, ftQuery, true)
+		results, total, err = runSearch(ctx, db, union, args, limit, offset)
+		if err != nil {
+			utils.Logger.Warnf("fulltext search failed, falling back to LIKE: %v", err)
+		} else if total > 0 {
+			mode = "fulltext"
+		}
+	}
+
+	if mode == "like" {
+		union, args := buildSearchQuery(sources, userID, q, false)
+		results, total, err = runSearch(ctx, db, union, args, limit, offset)
+		if err != nil {
+			utils.Logger.Errorf("search failed: %v", err)
+			utils.WriteError(w, "failed to search", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status": "success",
+		"query":  q,
+		"mode":   mode,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+		"data":   results,
+	})
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+)
+
+// WriteSettlementError maps errors returned by the settlement service to an HTTP response
+func WriteSettlementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAmount),
+		errors.Is(err, services.ErrOverpayment),
+		errors.Is(err, services.ErrWalletCurrency):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrInsufficientFunds):
+		utils.WriteError(w, err.Error(), http.StatusPaymentRequired)
+	case errors.Is(err, services.ErrWalletNotFound),
+		errors.Is(err, services.ErrSplitNotFound),
+		errors.Is(err, services.ErrExpenseNotFound),
+		errors.Is(err, services.ErrPaymentNotFound),
+		errors.Is(err, services.ErrRequestNotFound):
+		utils.WriteError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrPaymentAlreadyRefunded),
+		errors.Is(err, services.ErrCreditAlreadyApplied),
+		errors.Is(err, services.ErrRequestNotPending):
+		utils.WriteError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrNotSplitDebtor),
+		errors.Is(err, services.ErrNotPaymentCreditor),
+		errors.Is(err, services.ErrNotRequestRecipient),
+		errors.Is(err, services.ErrNotRequestRequester),
+		errors.Is(err, services.ErrNotWriteOffAuthorised):
+		utils.WriteError(w, err.Error(), http.StatusForbidden)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
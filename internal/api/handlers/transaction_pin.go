@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+)
+
+var (
+	ErrPinRequired  = errors.New("transaction pin is required")
+	ErrPinNotSet    = errors.New("transaction pin not set, please create one")
+	ErrPinLocked    = errors.New("transaction pin locked due to too many failed attempts, try again later or reset your pin")
+	ErrPinIncorrect = errors.New("incorrect transaction pin")
+)
+
+func pinMaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("PIN_MAX_ATTEMPTS"))
+	if err != nil || attempts <= 0 {
+		return 5
+	}
+	return attempts
+}
+
+func pinLockoutMinutes() int {
+	mins, err := strconv.Atoi(os.Getenv("PIN_LOCKOUT_DURATION"))
+	if err != nil || mins <= 0 {
+		return 30
+	}
+	return mins
+}
+
+// VerifyTransactionPin checks the user's PIN before a wallet debit, counting
+// failed attempts and locking the PIN once PIN_MAX_ATTEMPTS is reached. The PIN
+// row stays locked until the attempt is recorded, so parallel requests are
+// checked one at a time and cannot get more guesses than the limit allows.
+func VerifyTransactionPin(ctx context.Context, db *sql.DB, userID int, pin string) error {
+	if pin == "" {
+		return ErrPinRequired
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	var pinHash sql.NullString
+	var locked bool
+	var attempts int
+	err = tx.QueryRowContext(ctx, `
+		SELECT pin_hash, (locked_until IS NOT NULL AND locked_until > NOW()), failed_attempts
+		FROM transaction_pins WHERE user_id = ? FOR UPDATE
+	`, userID).Scan(&pinHash, &locked, &attempts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPinNotSet
+		}
+		return utils.ErrorHandler(err, "failed to fetch transaction pin")
+	}
+
+	if !pinHash.Valid || pinHash.String == "" {
+		return ErrPinNotSet
+	}
+
+	if locked {
+		return ErrPinLocked
+	}
+
+	if err := utils.VerifyPassword(pin, pinHash.String); err != nil {
+		attempts++
+		if attempts >= pinMaxAttempts() {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE transaction_pins SET failed_attempts = 0, locked_until = DATE_ADD(NOW(), INTERVAL ? MINUTE)
+				WHERE user_id = ?
+			`, pinLockoutMinutes(), userID)
+			if err != nil {
+				return utils.ErrorHandler(err, "failed to lock transaction pin")
+			}
+			if err := tx.Commit(); err != nil {
+				return utils.ErrorHandler(err, "failed to lock transaction pin")
+			}
+			utils.Logger.Warnf("transaction pin locked for user %d", userID)
+			return ErrPinLocked
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE transaction_pins SET failed_attempts = ? WHERE user_id = ?", attempts, userID)
+		if err != nil {
+			return utils.ErrorHandler(err, "failed to record pin attempt")
+		}
+		if err := tx.Commit(); err != nil {
+			return utils.ErrorHandler(err, "failed to record pin attempt")
+		}
+
+		return ErrPinIncorrect
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE transaction_pins SET failed_attempts = 0, locked_until = NULL WHERE user_id = ?", userID)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to reset pin attempts")
+	}
+	if err := tx.Commit(); err != nil {
+		return utils.ErrorHandler(err, "failed to reset pin attempts")
+	}
+
+	return nil
+}
+
+// WritePinError maps a VerifyTransactionPin error to an HTTP response
+func WritePinError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrPinRequired):
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrPinNotSet):
+		utils.WriteError(w, err.Error(), http.StatusPreconditionRequired)
+	case errors.Is(err, ErrPinLocked):
+		utils.WriteError(w, err.Error(), http.StatusLocked)
+	case errors.Is(err, ErrPinIncorrect):
+		utils.WriteError(w, err.Error(), http.StatusForbidden)
+	default:
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+	}
+}
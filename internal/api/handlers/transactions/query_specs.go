@@ -0,0 +1,34 @@
+package transactions
+
+import "qiyana_paybuddy/pkg/queryspec"
+
+var transactionSpec = queryspec.Spec{
+	Filters: map[string]queryspec.Field{
+		"transaction_type":     {Column: "transaction_type", Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}, Values: []string{"credit", "debit"}},
+		"category":             {Column: "category", Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}, Values: []string{"bill", "fund", "split", "refund", "request"}},
+		"status":               {Column: "status", Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}, Values: []string{"pending", "success", "failed"}},
+		"created_at":           {Column: "created_at", Kind: queryspec.Date, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+		"amount":               {Column: "amount", Kind: queryspec.Number, Ops: []queryspec.Operator{queryspec.Eq, queryspec.Gte, queryspec.Lte}},
+		"reference":            {Column: "reference", Ops: []queryspec.Operator{queryspec.Eq, queryspec.Like}, PrefixMatch: true},
+		"related_type":         {Column: "related_type", Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}, Values: []string{"split", "split_payment", "money_request", "paystack_charge"}},
+		"related_id":           {Column: "related_id", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq}},
+		"counterparty_user_id": {Column: "counterparty_user_id", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}},
+		"group_id":             {Column: "group_id", Kind: queryspec.Integer, Ops: []queryspec.Operator{queryspec.Eq, queryspec.In}},
+	},
+	Aliases: map[string]queryspec.Alias{
+		"transaction_type": {Field: "transaction_type", Op: queryspec.Eq},
+		"category":         {Field: "category", Op: queryspec.Eq},
+		"status":           {Field: "status", Op: queryspec.Eq},
+		"from":             {Field: "created_at", Op: queryspec.Gte},
+		"to":               {Field: "created_at", Op: queryspec.Lte},
+		"min_amount":       {Field: "amount", Op: queryspec.Gte},
+		"max_amount":       {Field: "amount", Op: queryspec.Lte},
+		"reference":        {Field: "reference", Op: queryspec.Like},
+	},
+	Sorts:       map[string]string{"created_at": "created_at", "amount": "amount"},
+	DefaultSort: []queryspec.SortTerm{{Column: "created_at", Desc: true}},
+	TieBreaker:  "id",
+	// keyset pagination needs a single sort column plus the id tie-breaker
+	MaxSorts: 1,
+	Search:   []string{"description", "reference"},
+}
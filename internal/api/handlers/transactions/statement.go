@@ -0,0 +1,154 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"qiyana_paybuddy/internal/repositories/sqlconnect"
+	"qiyana_paybuddy/internal/services"
+	"qiyana_paybuddy/pkg/utils"
+	"strconv"
+	"time"
+)
+
+const maxStatementDays = 366
+
+// FUNC TO DOWNLOAD OR EMAIL AN ACCOUNT STATEMENT
+func GetStatementHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.WriteError(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	db := sqlconnect.DB
+	if db == nil {
+		utils.Logger.Error("DB is not initialized")
+		utils.WriteError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	idFloat, ok := r.Context().Value(utils.ContextKey("userId")).(float64)
+	if !ok {
+		utils.WriteError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	userID := int(idFloat)
+
+	q := r.URL.Query()
+
+	// default to the current month so far
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, now.Location()); err != nil {
+			utils.WriteError(w, "from must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, now.Location()); err != nil {
+			utils.WriteError(w, "to must be in YYYY-MM-DD format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if to.Before(from) {
+		utils.WriteError(w, "to cannot be before from", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > maxStatementDays*24*time.Hour {
+		utils.WriteError(w, "statement period cannot be longer than one year", http.StatusBadRequest)
+		return
+	}
+
+	format := q.Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if format != "pdf" && format != "csv" {
+		utils.WriteError(w, "format must be csv or pdf", http.StatusBadRequest)
+		return
+	}
+
+	sendEmail := false
+	if v := q.Get("email"); v != "" {
+		if sendEmail, err = strconv.ParseBool(v); err != nil {
+			utils.WriteError(w, "email must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	statement, err := services.BuildStatement(ctx, db, userID, from, to)
+	if err != nil {
+		if errors.Is(err, services.ErrWalletNotFound) {
+			utils.WriteError(w, "wallet not found", http.StatusNotFound)
+			return
+		}
+		utils.WriteError(w, "failed to generate statement", http.StatusInternalServerError)
+		return
+	}
+
+	var content []byte
+	contentType := "application/pdf"
+	if format == "csv" {
+		contentType = "text/csv"
+		if content, err = statement.CSV(); err != nil {
+			utils.Logger.Errorf("failed to render csv statement: %v", err)
+			utils.WriteError(w, "failed to generate statement", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		content = statement.PDF()
+	}
+
+	if !sendEmail {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+statement.Filename(format)+`"`)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(content)
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "statement-*")
+	if err != nil {
+		utils.Logger.Errorf("failed to create statement temp dir: %v", err)
+		utils.WriteError(w, "failed to email statement", http.StatusInternalServerError)
+		return
+	}
+	path := filepath.Join(dir, statement.Filename(format))
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		os.RemoveAll(dir)
+		utils.Logger.Errorf("failed to write statement file: %v", err)
+		utils.WriteError(w, "failed to email statement", http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		defer os.RemoveAll(dir)
+		if err := utils.SendStatementEmail(statement.Email, statement.AccountName, statement.From, statement.To,
+			statement.OpeningBalance.StringFixed(2), statement.ClosingBalance.StringFixed(2), format, path); err != nil {
+			utils.Logger.Errorf("failed to send statement email to %s: %v", statement.Email, err)
+		}
+	}()
+
+	utils.WriteJSON(w, map[string]interface{}{
+		"status":  "success",
+		"message": "statement sent to your email",
+		"data": map[string]interface{}{
+			"from":            statement.From.Format("2006-01-02"),
+			"to":              statement.To.Format("2006-01-02"),
+			"opening_balance": statement.OpeningBalance,
+			"closing_balance": statement.ClosingBalance,
+			"transactions":    len(statement.Entries),
+		},
+	})
+}
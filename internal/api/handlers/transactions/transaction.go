@@ -3,13 +3,15 @@ package transactions
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"qiyana_paybuddy/internal/models"
 	"qiyana_paybuddy/internal/repositories/sqlconnect"
 	"qiyana_paybuddy/pkg/utils"
 	"strconv"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // FUNC TO GET ALL TRANSACTIONS FOR A USER
@@ -33,23 +35,55 @@ func GetAllUserTransactions(w http.ResponseWriter, r *http.Request) {
 	}
 	userID := int(idFloat)
 
+	_, limit := utils.GetPaginationParams(r)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	listQuery, err := transactionSpec.Parse(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sortTerm := listQuery.Sorts[0]
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	page, limit := utils.GetPaginationParams(r)
-	offset := (page - 1) * limit
+	baseArgs := append([]interface{}{userID}, listQuery.Args...)
+
+	var total int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM transactions WHERE user_id = ?"+listQuery.Where, baseArgs...).Scan(&total)
+	if err != nil {
+		utils.Logger.Errorf("error counting transactions: %v", err)
+		utils.WriteError(w, "error fetching transactions", http.StatusInternalServerError)
+		return
+	}
 
 	query := `
-		SELECT id, transaction_type, category, amount, status, reference, description, created_at, updated_at 
+		SELECT id, transaction_type, category, amount, status, reference, description, reversal_of,
+			related_type, related_id, counterparty_user_id, group_id, created_at, updated_at
 		FROM transactions
-		WHERE user_id = ?
-	`
-	args := []interface{}{userID}
+		WHERE user_id = ?` + listQuery.Where
+	args := baseArgs
 
-	query += " LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	// keyset pagination: continue strictly after the last row of the previous page
+	if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+		sortValue, lastID, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			utils.WriteError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cmp := ">"
+		if sortTerm.Desc {
+			cmp = "<"
+		}
+		query += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND id %s ?))", sortTerm.Column, cmp, sortTerm.Column, cmp)
+		args = append(args, sortValue, sortValue, lastID)
+	}
 
-	query = utils.AddSorting(r, query)
+	query += listQuery.OrderBy() + " LIMIT ?"
+	args = append(args, limit+1)
 
 	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -59,10 +93,11 @@ func GetAllUserTransactions(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
-	var transactions []models.Transaction
+	transactions := make([]models.Transaction, 0, limit)
 	for rows.Next() {
 		var transaction models.Transaction
-		err = rows.Scan(&transaction.ID, &transaction.TransactionType, &transaction.Category, &transaction.Amount, &transaction.Status, &transaction.Reference, &transaction.Description, &transaction.CreatedAt, &transaction.UpdatedAt)
+		err = rows.Scan(&transaction.ID, &transaction.TransactionType, &transaction.Category, &transaction.Amount, &transaction.Status, &transaction.Reference, &transaction.Description, &transaction.ReversalOf,
+			&transaction.RelatedType, &transaction.RelatedID, &transaction.CounterpartyUserID, &transaction.GroupID, &transaction.CreatedAt, &transaction.UpdatedAt)
 		if err != nil {
 			utils.Logger.Errorf("error fetching data: %v", err)
 			utils.WriteError(w, "error fetching transaction", http.StatusInternalServerError)
@@ -71,29 +106,31 @@ func GetAllUserTransactions(w http.ResponseWriter, r *http.Request) {
 		transactions = append(transactions, transaction)
 	}
 
-	if len(transactions) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		response := map[string]interface{}{
-			"status":  "success",
-			"message": "no transaction found for this user",
-			"data":    []models.Transaction{},
+	var nextCursor string
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		sortValue := last.CreatedAt.String
+		if sortTerm.Column == "amount" {
+			sortValue = last.Amount.String()
 		}
-		json.NewEncoder(w).Encode(response)
-		return
+		nextCursor = utils.EncodeCursor(sortValue, last.ID)
 	}
 
 	response := struct {
-		Status   string               `json:"status"`
-		Count    int                  `json:"count"`
-		Page     int                  `json:"page"`
-		PageSize int                  `json:"page_size"`
-		Data     []models.Transaction `json:"data"`
+		Status     string               `json:"status"`
+		Count      int                  `json:"count"`
+		Total      int                  `json:"total"`
+		PageSize   int                  `json:"page_size"`
+		NextCursor string               `json:"next_cursor,omitempty"`
+		Data       []models.Transaction `json:"data"`
 	}{
-		Status:   "success",
-		Count:    len(transactions),
-		Page:     page,
-		PageSize: limit,
-		Data:     transactions,
+		Status:     "success",
+		Count:      len(transactions),
+		Total:      total,
+		PageSize:   limit,
+		NextCursor: nextCursor,
+		Data:       transactions,
 	}
 
 	utils.WriteJSON(w, response)
@@ -131,7 +168,12 @@ func GetTransactionById(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var transaction models.Transaction
-	err = db.QueryRowContext(ctx, "SELECT transaction_type, category, amount, status, reference, description, created_at, updated_at FROM transactions WHERE id = ? AND user_id = ?", transactionID, userID).Scan(&transaction.TransactionType, &transaction.Category, &transaction.Amount, &transaction.Status, &transaction.Reference, &transaction.Description, &transaction.CreatedAt, &transaction.UpdatedAt)
+	err = db.QueryRowContext(ctx, `
+		SELECT id, transaction_type, category, amount, status, reference, description, reversal_of,
+			related_type, related_id, counterparty_user_id, group_id, created_at, updated_at
+		FROM transactions WHERE id = ? AND user_id = ?
+	`, transactionID, userID).Scan(&transaction.ID, &transaction.TransactionType, &transaction.Category, &transaction.Amount, &transaction.Status, &transaction.Reference, &transaction.Description, &transaction.ReversalOf,
+		&transaction.RelatedType, &transaction.RelatedID, &transaction.CounterpartyUserID, &transaction.GroupID, &transaction.CreatedAt, &transaction.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.WriteError(w, "no transaction found", http.StatusNotFound)
@@ -142,13 +184,126 @@ func GetTransactionById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	details, err := loadTransactionDetails(ctx, db, transaction)
+	if err != nil {
+		utils.Logger.Errorf("error fetching transaction details: %v", err)
+		utils.WriteError(w, "error fetching transaction", http.StatusInternalServerError)
+		return
+	}
+
 	response := struct {
-		Status string             `json:"status"`
-		Data   models.Transaction `json:"data"`
+		Status  string             `json:"status"`
+		Data    models.Transaction `json:"data"`
+		Details transactionDetails `json:"details"`
 	}{
-		Status: "success",
-		Data:   transaction,
+		Status:  "success",
+		Data:    transaction,
+		Details: details,
 	}
 
 	utils.WriteJSON(w, response)
 }
+
+type transactionCounterparty struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type transactionGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type transactionExpense struct {
+	ID          int             `json:"id"`
+	SplitID     int             `json:"split_id"`
+	Description string          `json:"description"`
+	Amount      decimal.Decimal `json:"amount"`
+	PaidBy      int             `json:"paid_by"`
+	CreatedAt   sql.NullString  `json:"created_at"`
+}
+
+type transactionMoneyRequest struct {
+	ID      int            `json:"id"`
+	Reason  string         `json:"reason"`
+	Status  string         `json:"status"`
+	DueDate sql.NullString `json:"due_date"`
+}
+
+type transactionDetails struct {
+	Counterparty *transactionCounterparty `json:"counterparty,omitempty"`
+	Group        *transactionGroup        `json:"group,omitempty"`
+	Expense      *transactionExpense      `json:"expense,omitempty"`
+	MoneyRequest *transactionMoneyRequest `json:"money_request,omitempty"`
+}
+
+// loadTransactionDetails expands the counterparty, group and source record a transaction links to.
+// Linked rows that have since been deleted are left out rather than treated as errors.
+func loadTransactionDetails(ctx context.Context, db *sql.DB, t models.Transaction) (transactionDetails, error) {
+	var d transactionDetails
+
+	if t.CounterpartyUserID.Valid {
+		d.Counterparty = &transactionCounterparty{}
+		err := db.QueryRowContext(ctx, "SELECT id, username, first_name, last_name FROM users WHERE id = ?", t.CounterpartyUserID.Int64).
+			Scan(&d.Counterparty.ID, &d.Counterparty.Username, &d.Counterparty.FirstName, &d.Counterparty.LastName)
+		if err == sql.ErrNoRows {
+			d.Counterparty = nil
+		} else if err != nil {
+			return d, err
+		}
+	}
+
+	if t.GroupID.Valid {
+		d.Group = &transactionGroup{}
+		err := db.QueryRowContext(ctx, "SELECT id, name FROM groups WHERE id = ?", t.GroupID.Int64).Scan(&d.Group.ID, &d.Group.Name)
+		if err == sql.ErrNoRows {
+			d.Group = nil
+		} else if err != nil {
+			return d, err
+		}
+	}
+
+	if !t.RelatedType.Valid || !t.RelatedID.Valid {
+		return d, nil
+	}
+
+	switch t.RelatedType.String {
+	case "split", "split_payment":
+		splitID := t.RelatedID.Int64
+		if t.RelatedType.String == "split_payment" {
+			err := db.QueryRowContext(ctx, "SELECT split_id FROM split_payments WHERE id = ?", t.RelatedID.Int64).Scan(&splitID)
+			if err == sql.ErrNoRows {
+				return d, nil
+			} else if err != nil {
+				return d, err
+			}
+		}
+
+		d.Expense = &transactionExpense{}
+		err := db.QueryRowContext(ctx, `
+			SELECT e.id, s.id, e.description, e.amount, e.paid_by, e.created_at
+			FROM group_expense_splits s
+			JOIN group_expenses e ON e.id = s.expense_id
+			WHERE s.id = ?
+		`, splitID).Scan(&d.Expense.ID, &d.Expense.SplitID, &d.Expense.Description, &d.Expense.Amount, &d.Expense.PaidBy, &d.Expense.CreatedAt)
+		if err == sql.ErrNoRows {
+			d.Expense = nil
+		} else if err != nil {
+			return d, err
+		}
+
+	case "money_request":
+		d.MoneyRequest = &transactionMoneyRequest{}
+		err := db.QueryRowContext(ctx, "SELECT id, reason, status, due_date FROM money_requests WHERE id = ?", t.RelatedID.Int64).
+			Scan(&d.MoneyRequest.ID, &d.MoneyRequest.Reason, &d.MoneyRequest.Status, &d.MoneyRequest.DueDate)
+		if err == sql.ErrNoRows {
+			d.MoneyRequest = nil
+		} else if err != nil {
+			return d, err
+		}
+	}
+
+	return d, nil
+}
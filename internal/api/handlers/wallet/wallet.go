@@ -126,6 +126,7 @@ func PaystackWebhook(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Event string `json:"event"`
 		Data  struct {
+			ID        int64                  `json:"id"`
 			Reference string                 `json:"reference"`
 			Amount    int                    `json:"amount"`
 			Metadata  map[string]interface{} `json:"metadata"`
@@ -226,9 +227,9 @@ func PaystackWebhook(w http.ResponseWriter, r *http.Request) {
 
 	amount := decimal.NewFromInt(int64(amountNaira))
 	_, err = tx.Exec(`
-	INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description) 
-	VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		userID, transactionType, category, amount, "success", reference, description)
+	INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description, related_type, related_id) 
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, transactionType, category, amount, "success", reference, description, "paystack_charge", payload.Data.ID)
 
 	if err != nil {
 		tx.Rollback()
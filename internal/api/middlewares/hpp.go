@@ -11,6 +11,8 @@ type HPPOptions struct {
 	CheckBody                   bool
 	CheckBodyOnlyForContentType string
 	Whitelist                   []string
+	// WhitelistPrefixes allows bracketed params such as filter[amount][gte]
+	WhitelistPrefixes []string
 }
 
 func Hpp(options HPPOptions) func(http.Handler) http.Handler {
@@ -20,11 +22,11 @@ func Hpp(options HPPOptions) func(http.Handler) http.Handler {
 			fmt.Println("Hpp Middleware being Returned...")
 			if options.CheckBody && r.Method == http.MethodPost && isCorrectContentType(r, options.CheckBodyOnlyForContentType) {
 				// filter the body params
-				filterBodyParams(r, options.Whitelist)
+				filterBodyParams(r, options)
 			}
 			if options.CheckQuery && r.URL.Query() != nil {
 				// filter the query params
-				filterQueryParams(r, options.Whitelist)
+				filterQueryParams(r, options)
 			}
 			next.ServeHTTP(w, r)
 			fmt.Println("Hpp Middleware Ends...")
@@ -36,7 +38,7 @@ func isCorrectContentType(r *http.Request, contentType string) bool {
 	return strings.Contains(r.Header.Get("Content-Type"), contentType)
 }
 
-func filterBodyParams(r *http.Request, whitelist []string) {
+func filterBodyParams(r *http.Request, options HPPOptions) {
 	err := r.ParseForm()
 	if err != nil {
 		fmt.Println(err)
@@ -48,14 +50,14 @@ func filterBodyParams(r *http.Request, whitelist []string) {
 			r.Form.Set(k, v[0]) // first value
 			// r.Form.Set(k, v[len(v)-1]) // this will accept the last value
 		}
-		if !isWhiteListed(k, whitelist) {
+		if !isWhiteListed(k, options) {
 			delete(r.Form, k)
 		}
 	}
 
 }
 
-func filterQueryParams(r *http.Request, whitelist []string) {
+func filterQueryParams(r *http.Request, options HPPOptions) {
 	query := r.URL.Query()
 
 	for k, v := range query {
@@ -63,7 +65,7 @@ func filterQueryParams(r *http.Request, whitelist []string) {
 			query.Set(k, v[0]) // first value
 			// query.Set(k, v[len(v)-1]) // this will accept the last value
 		}
-		if !isWhiteListed(k, whitelist) {
+		if !isWhiteListed(k, options) {
 			query.Del(k)
 		}
 	}
@@ -72,11 +74,16 @@ func filterQueryParams(r *http.Request, whitelist []string) {
 
 }
 
-func isWhiteListed(param string, whitelist []string) bool {
-	for _, v := range whitelist {
+func isWhiteListed(param string, options HPPOptions) bool {
+	for _, v := range options.Whitelist {
 		if param == v {
 			return true
 		}
 	}
+	for _, prefix := range options.WhitelistPrefixes {
+		if strings.HasPrefix(param, prefix) {
+			return true
+		}
+	}
 	return false
 }
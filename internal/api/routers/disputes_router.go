@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers/disputes"
+)
+
+func disputesRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/disputes/create", disputes.CreateDisputeHandler)
+
+	mux.HandleFunc("/disputes/", disputes.GetDisputesHandler)
+
+	mux.HandleFunc("/disputes/{id}", disputes.GetDisputeByIdHandler)
+
+	mux.HandleFunc("/disputes/{id}/respond", disputes.RespondToDisputeHandler)
+
+	mux.HandleFunc("/disputes/{id}/resolve", disputes.ResolveDisputeHandler)
+
+	mux.HandleFunc("/disputes/{id}/reject", disputes.RejectDisputeHandler)
+
+	return mux
+}
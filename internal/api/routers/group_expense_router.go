@@ -22,7 +22,19 @@ func groupExpenseRouter() *http.ServeMux {
 
 	mux.HandleFunc("/group-expense/{split_id}/settle", groups.SettleExpenseSplitHandler)
 
+	mux.HandleFunc("/group-expense/{split_id}/payments", groups.GetSplitPaymentsHandler)
+
+	mux.HandleFunc("/group-expense/{split_id}/refund", groups.RefundSplitPaymentHandler)
+
+	mux.HandleFunc("/group-expense/{split_id}/settle-offline", groups.RecordOfflineSplitPaymentHandler)
+
 	mux.HandleFunc("/group-expense/delete/{expense_id}/expense", groups.DeleteExpenseHandler)
 
+	mux.HandleFunc("/group-expense/{id}/pending", groups.GetPendingExpensesHandler)
+
+	mux.HandleFunc("/group-expense/approvals/{approval_id}/approve", groups.ApproveExpenseHandler)
+
+	mux.HandleFunc("/group-expense/approvals/{approval_id}/reject", groups.RejectExpenseHandler)
+
 	return mux
 }
@@ -12,6 +12,8 @@ func groupsRouter() *http.ServeMux {
 
 	mux.HandleFunc("/groups/", groups.GetMyGroupsHandler)
 
+	mux.HandleFunc("/groups/public", groups.GetPublicGroupsHandler)
+
 	mux.HandleFunc("/groups/{id}", groups.GetGroupByIDHandler)
 
 	mux.HandleFunc("/groups/delete/{id}", groups.DeleteGroupByHandler)
@@ -34,5 +36,53 @@ func groupsRouter() *http.ServeMux {
 
 	mux.HandleFunc("/groups/{groupId}/invites/{inviteId}/resend", groups.ResendInviteHandler)
 
+	mux.HandleFunc("/groups/{id}/invites/history", groups.GetMembershipHistoryHandler)
+
+	mux.HandleFunc("/groups/{id}/categories/create", groups.CreateGroupCategoryHandler)
+
+	mux.HandleFunc("/groups/{id}/categories/{categoryId}/delete", groups.DeleteGroupCategoryHandler)
+
+	mux.HandleFunc("/groups/{id}/budgets/set", groups.SetGroupBudgetHandler)
+
+	mux.HandleFunc("/groups/{id}/budgets/{budgetId}/delete", groups.DeleteGroupBudgetHandler)
+
+	mux.HandleFunc("/groups/{id}/purge/request", groups.RequestGroupPurgeHandler)
+
+	mux.HandleFunc("/groups/{id}/purge/confirm", groups.ConfirmGroupPurgeHandler)
+
+	mux.HandleFunc("/groups/{id}/purge/cancel", groups.CancelGroupPurgeHandler)
+
+	mux.HandleFunc("/groups/{id}/members/{userId}/promote", groups.PromoteMemberHandler)
+
+	mux.HandleFunc("/groups/{id}/members/{userId}/demote", groups.DemoteMemberHandler)
+
+	mux.HandleFunc("/groups/{id}/settings/update", groups.UpdateGroupSettingsHandler)
+
+	mux.HandleFunc("/groups/join/{code}", groups.JoinGroupByLinkHandler)
+
+	mux.HandleFunc("/groups/{id}/join-links/create", groups.CreateJoinLinkHandler)
+
+	mux.HandleFunc("/groups/{id}/join-links/{linkId}/rotate", groups.RotateJoinLinkHandler)
+
+	mux.HandleFunc("/groups/{id}/join-links/{linkId}/revoke", groups.RevokeJoinLinkHandler)
+
+	mux.HandleFunc("/groups/{id}/join-links/{linkId}/qr", groups.GetJoinLinkQRHandler)
+
+	mux.HandleFunc("/groups/{id}/join-requests/create", groups.RequestToJoinGroupHandler)
+
+	mux.HandleFunc("/groups/{id}/join-requests/{requestId}/approve", groups.ApproveJoinRequestHandler)
+
+	mux.HandleFunc("/groups/{id}/join-requests/{requestId}/reject", groups.RejectJoinRequestHandler)
+
+	mux.HandleFunc("/groups/{id}/guests/create", groups.CreateGroupGuestHandler)
+
+	mux.HandleFunc("/groups/{id}/guests/{guestId}/update", groups.UpdateGroupGuestHandler)
+
+	mux.HandleFunc("/groups/{id}/guests/{guestId}/remove", groups.RemoveGroupGuestHandler)
+
+	mux.HandleFunc("/groups/{id}/guests/{guestId}/splits", groups.GetGuestSplitsHandler)
+
+	mux.HandleFunc("/groups/{id}/guests/{guestId}/splits/{splitId}/settle", groups.SettleGuestSplitHandler)
+
 	return mux
 }
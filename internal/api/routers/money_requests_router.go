@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers/requests"
+)
+
+func moneyRequestsRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/money-requests/create", requests.CreateMoneyRequestHandler)
+
+	mux.HandleFunc("/money-requests/inbox", requests.GetMoneyRequestInboxHandler)
+
+	mux.HandleFunc("/money-requests/sent", requests.GetSentMoneyRequestsHandler)
+
+	mux.HandleFunc("/money-requests/{id}/accept", requests.AcceptMoneyRequestHandler)
+
+	mux.HandleFunc("/money-requests/{id}/decline", requests.DeclineMoneyRequestHandler)
+
+	mux.HandleFunc("/money-requests/{id}/cancel", requests.CancelMoneyRequestHandler)
+
+	return mux
+}
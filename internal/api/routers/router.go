@@ -2,6 +2,7 @@ package routers
 
 import (
 	"net/http"
+	"qiyana_paybuddy/internal/api/handlers/groups"
 )
 
 func MainRouter() *http.ServeMux {
@@ -13,12 +14,32 @@ func MainRouter() *http.ServeMux {
 
 	apiMux.Handle("/groups/", groupsRouter())
 
+	// these overlap /groups/delete/{id} and /groups/update/{id} inside groupsRouter,
+	// so they are matched here before the request reaches it
+	apiMux.HandleFunc("/groups/{id}/summary", groups.GetGroupSummaryHandler)
+	apiMux.HandleFunc("/groups/{id}/analytics", groups.GetGroupAnalyticsHandler)
+	apiMux.HandleFunc("/groups/{id}/categories", groups.GetGroupCategoriesHandler)
+	apiMux.HandleFunc("/groups/{id}/budgets", groups.GetGroupBudgetsHandler)
+	apiMux.HandleFunc("/groups/{id}/restore", groups.RestoreGroupHandler)
+	apiMux.HandleFunc("/groups/{id}/export", groups.ExportGroupHandler)
+	apiMux.HandleFunc("/groups/{id}/transfer-ownership", groups.TransferOwnershipHandler)
+	apiMux.HandleFunc("/groups/{id}/settings", groups.GroupSettingsHandler)
+	apiMux.HandleFunc("/groups/{id}/join-links", groups.GetJoinLinksHandler)
+	apiMux.HandleFunc("/groups/{id}/join-requests", groups.GetJoinRequestsHandler)
+	apiMux.HandleFunc("/groups/{id}/guests", groups.GetGroupGuestsHandler)
+
 	apiMux.Handle("/wallet/", walletRouter())
 
 	apiMux.Handle("/group-expense/", groupExpenseRouter())
 
 	apiMux.Handle("/transactions/", transactionsRouter())
 
+	apiMux.Handle("/money-requests/", moneyRequestsRouter())
+
+	apiMux.Handle("/disputes/", disputesRouter())
+
+	apiMux.Handle("/search", searchRouter())
+
 	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", apiMux))
 
 	return mux
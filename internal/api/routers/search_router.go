@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"net/http"
+	"qiyana_paybuddy/internal/api/handlers/search"
+)
+
+func searchRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/search", search.SearchHandler)
+
+	return mux
+}
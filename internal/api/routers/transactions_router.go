@@ -12,5 +12,7 @@ func transactionsRouter() *http.ServeMux {
 
 	mux.HandleFunc("/transactions/{id}/user", transactions.GetTransactionById)
 
+	mux.HandleFunc("/transactions/statement", transactions.GetStatementHandler)
+
 	return mux
 }
@@ -3,6 +3,8 @@ package routers
 import (
 	"net/http"
 	"qiyana_paybuddy/internal/api/handlers/auth"
+	"qiyana_paybuddy/internal/api/handlers/groups"
+	"qiyana_paybuddy/internal/api/handlers/notifications"
 )
 
 func usersRouter() *http.ServeMux {
@@ -18,5 +20,23 @@ func usersRouter() *http.ServeMux {
 	mux.HandleFunc("/users/resetpassword/reset/{resetcode}", auth.ResetPasswordHandler)
 	mux.HandleFunc("/users/updatepassword", auth.UpdatePasswordHandler)
 
+	mux.HandleFunc("/users/pin/otp", auth.RequestPinOtpHandler)
+	mux.HandleFunc("/users/pin/set", auth.SetPinHandler)
+	mux.HandleFunc("/users/pin/change", auth.ChangePinHandler)
+	mux.HandleFunc("/users/pin/reset", auth.ResetPinHandler)
+
+	mux.HandleFunc("/users/preferences", auth.GetPreferencesHandler)
+	mux.HandleFunc("/users/preferences/update", auth.UpdatePreferencesHandler)
+
+	mux.HandleFunc("/users/me/insights", auth.GetMyInsightsHandler)
+
+	mux.HandleFunc("/users/me/invitations", groups.GetMyInvitationsHandler)
+	mux.HandleFunc("/users/me/invitations/{id}/accept", groups.AcceptMyInvitationHandler)
+	mux.HandleFunc("/users/me/invitations/{id}/decline", groups.DeclineMyInvitationHandler)
+
+	mux.HandleFunc("/users/me/notifications", notifications.GetMyNotificationsHandler)
+	mux.HandleFunc("/users/me/notifications/read-all", notifications.MarkAllNotificationsReadHandler)
+	mux.HandleFunc("/users/me/notifications/{id}/read", notifications.MarkNotificationReadHandler)
+
 	return mux
 }
@@ -0,0 +1,25 @@
+ALTER TABLE transactions
+    MODIFY category ENUM('bill', 'fund', 'split', 'refund') NOT NULL,
+    ADD COLUMN reversal_of INT NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_transaction_reversal FOREIGN KEY (reversal_of) REFERENCES transactions(id);
+
+CREATE TABLE IF NOT EXISTS split_payments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    split_id INT NOT NULL,
+    payer_id INT NOT NULL,
+    payee_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    debit_transaction_id INT NOT NULL,
+    credit_transaction_id INT NOT NULL,
+    status ENUM('completed', 'refunded') NOT NULL DEFAULT 'completed',
+    refund_debit_transaction_id INT NULL DEFAULT NULL,
+    refund_credit_transaction_id INT NULL DEFAULT NULL,
+    refunded_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_payment_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payment_payer FOREIGN KEY (payer_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payment_payee FOREIGN KEY (payee_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payment_debit FOREIGN KEY (debit_transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_payment_credit FOREIGN KEY (credit_transaction_id) REFERENCES transactions(id),
+    INDEX idx_payment_split (split_id)
+);
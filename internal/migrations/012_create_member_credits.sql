@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS member_credits (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    from_user_id INT NOT NULL,
+    to_user_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    remaining DECIMAL(18, 2) NOT NULL,
+    source_payment_id INT NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_credit_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_credit_from_user FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_credit_to_user FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_credit_payment FOREIGN KEY (source_payment_id) REFERENCES split_payments(id) ON DELETE SET NULL,
+    INDEX idx_credit_pair (group_id, from_user_id, to_user_id)
+);
+
+CREATE TABLE IF NOT EXISTS member_credit_applications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    credit_id INT NOT NULL,
+    split_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_application_credit FOREIGN KEY (credit_id) REFERENCES member_credits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_application_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id) ON DELETE CASCADE
+);
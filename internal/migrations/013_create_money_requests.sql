@@ -0,0 +1,25 @@
+ALTER TABLE transactions
+    MODIFY category ENUM('bill', 'fund', 'split', 'refund', 'request') NOT NULL;
+
+CREATE TABLE IF NOT EXISTS money_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    requester_id INT NOT NULL,
+    recipient_id INT NOT NULL,
+    group_id INT NULL DEFAULT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    due_date DATE NULL DEFAULT NULL,
+    status ENUM('pending', 'paid', 'declined', 'cancelled') NOT NULL DEFAULT 'pending',
+    debit_transaction_id INT NULL DEFAULT NULL,
+    credit_transaction_id INT NULL DEFAULT NULL,
+    responded_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_request_requester FOREIGN KEY (requester_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_request_recipient FOREIGN KEY (recipient_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_request_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE SET NULL,
+    CONSTRAINT fk_request_debit FOREIGN KEY (debit_transaction_id) REFERENCES transactions(id),
+    CONSTRAINT fk_request_credit FOREIGN KEY (credit_transaction_id) REFERENCES transactions(id),
+    INDEX idx_request_recipient (recipient_id, status),
+    INDEX idx_request_requester (requester_id, status)
+);
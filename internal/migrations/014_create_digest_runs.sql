@@ -0,0 +1,14 @@
+ALTER TABLE user_preferences
+    ADD COLUMN monthly_digest_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS digest_runs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    period CHAR(7) NOT NULL,
+    status ENUM('sending', 'sent', 'failed') NOT NULL DEFAULT 'sending',
+    sent_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_digest_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uq_digest_user_period (user_id, period)
+);
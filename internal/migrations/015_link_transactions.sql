@@ -0,0 +1,35 @@
+ALTER TABLE transactions
+    ADD COLUMN related_type ENUM('split', 'split_payment', 'money_request', 'paystack_charge') NULL DEFAULT NULL,
+    ADD COLUMN related_id BIGINT NULL DEFAULT NULL,
+    ADD COLUMN counterparty_user_id INT NULL DEFAULT NULL,
+    ADD COLUMN group_id INT NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_transaction_counterparty FOREIGN KEY (counterparty_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT fk_transaction_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE SET NULL,
+    ADD INDEX idx_transaction_related (related_type, related_id);
+
+-- backfill split payments and their refunds
+UPDATE transactions t
+JOIN split_payments p ON t.id IN (p.debit_transaction_id, p.credit_transaction_id)
+JOIN group_expense_splits s ON s.id = p.split_id
+JOIN group_expenses e ON e.id = s.expense_id
+SET t.related_type = 'split',
+    t.related_id = p.split_id,
+    t.group_id = e.group_id,
+    t.counterparty_user_id = IF(t.id = p.debit_transaction_id, p.payee_id, p.payer_id);
+
+UPDATE transactions t
+JOIN split_payments p ON t.id IN (p.refund_debit_transaction_id, p.refund_credit_transaction_id)
+JOIN group_expense_splits s ON s.id = p.split_id
+JOIN group_expenses e ON e.id = s.expense_id
+SET t.related_type = 'split_payment',
+    t.related_id = p.id,
+    t.group_id = e.group_id,
+    t.counterparty_user_id = IF(t.id = p.refund_debit_transaction_id, p.payer_id, p.payee_id);
+
+-- backfill paid money requests
+UPDATE transactions t
+JOIN money_requests r ON t.id IN (r.debit_transaction_id, r.credit_transaction_id)
+SET t.related_type = 'money_request',
+    t.related_id = r.id,
+    t.group_id = r.group_id,
+    t.counterparty_user_id = IF(t.id = r.debit_transaction_id, r.requester_id, r.recipient_id);
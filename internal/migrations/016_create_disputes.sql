@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS disputes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    subject_type ENUM('transaction', 'split') NOT NULL,
+    subject_id INT NOT NULL,
+    opened_by INT NOT NULL,
+    counterparty_id INT NULL DEFAULT NULL,
+    group_id INT NULL DEFAULT NULL,
+    split_id INT NULL DEFAULT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    evidence TEXT NULL,
+    status ENUM('open', 'responded', 'resolved', 'rejected') NOT NULL DEFAULT 'open',
+    response TEXT NULL,
+    responded_at DATETIME NULL DEFAULT NULL,
+    resolution_note VARCHAR(500) NULL DEFAULT NULL,
+    resolved_by INT NULL DEFAULT NULL,
+    resolved_at DATETIME NULL DEFAULT NULL,
+    reversal_payment_id INT NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_dispute_opener FOREIGN KEY (opened_by) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_dispute_counterparty FOREIGN KEY (counterparty_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_dispute_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE SET NULL,
+    CONSTRAINT fk_dispute_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id) ON DELETE SET NULL,
+    CONSTRAINT fk_dispute_resolver FOREIGN KEY (resolved_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_dispute_reversal FOREIGN KEY (reversal_payment_id) REFERENCES split_payments(id) ON DELETE SET NULL,
+    INDEX idx_dispute_subject (subject_type, subject_id),
+    INDEX idx_dispute_split_status (split_id, status)
+);
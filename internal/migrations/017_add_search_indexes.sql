@@ -0,0 +1,7 @@
+ALTER TABLE group_expenses ADD FULLTEXT INDEX ft_expense_description (description);
+
+ALTER TABLE groups ADD FULLTEXT INDEX ft_group_name_description (name, description);
+
+ALTER TABLE transactions ADD FULLTEXT INDEX ft_transaction_description_reference (description, reference);
+
+ALTER TABLE users ADD FULLTEXT INDEX ft_user_username (username);
@@ -0,0 +1,15 @@
+ALTER TABLE group_expense_splits
+    ADD COLUMN share_amount DECIMAL(18, 2) NOT NULL DEFAULT 0.00 AFTER owed_by;
+
+-- splits were always created as equal shares of the expense between the payer and members
+UPDATE group_expense_splits s
+JOIN (
+    SELECT e.id, ROUND(e.amount / (COUNT(s2.id) + 1), 2) AS share
+    FROM group_expenses e
+    JOIN group_expense_splits s2 ON s2.expense_id = e.id
+    GROUP BY e.id, e.amount
+) shares ON shares.id = s.expense_id
+SET s.share_amount = shares.share;
+
+UPDATE groups g
+SET g.total_expense = (SELECT COALESCE(SUM(e.amount), 0) FROM group_expenses e WHERE e.group_id = g.id);
@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS expense_categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NULL DEFAULT NULL,
+    name VARCHAR(50) NOT NULL,
+    created_by INT NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_category_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_category_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_group_category (group_id, name)
+);
+
+-- categories without a group are the defaults every group can use. The unique
+-- key ignores rows with a NULL group_id, so re-running must check for them itself.
+INSERT INTO expense_categories (name)
+SELECT defaults.name FROM (
+    SELECT 'Food & Drinks' AS name UNION ALL SELECT 'Groceries' UNION ALL SELECT 'Transport'
+    UNION ALL SELECT 'Rent' UNION ALL SELECT 'Utilities' UNION ALL SELECT 'Entertainment'
+    UNION ALL SELECT 'Travel' UNION ALL SELECT 'Shopping' UNION ALL SELECT 'Health'
+    UNION ALL SELECT 'Other'
+) defaults
+WHERE NOT EXISTS (
+    SELECT 1 FROM expense_categories c WHERE c.group_id IS NULL AND c.name = defaults.name
+);
+
+ALTER TABLE group_expenses
+    ADD COLUMN category_id INT NULL DEFAULT NULL AFTER description,
+    ADD CONSTRAINT fk_expense_category FOREIGN KEY (category_id) REFERENCES expense_categories(id) ON DELETE SET NULL;
+
+-- a budget without a category caps the group's overall monthly spending
+CREATE TABLE IF NOT EXISTS group_budgets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    category_id INT NULL DEFAULT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    created_by INT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_budget_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_budget_category FOREIGN KEY (category_id) REFERENCES expense_categories(id) ON DELETE CASCADE,
+    CONSTRAINT fk_budget_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_budget_group (group_id, category_id)
+);
+
+-- one row per threshold crossed per month so members are only alerted once
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    budget_id INT NOT NULL,
+    period CHAR(7) NOT NULL,
+    threshold TINYINT NOT NULL,
+    spent DECIMAL(18, 2) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_alert_budget FOREIGN KEY (budget_id) REFERENCES group_budgets(id) ON DELETE CASCADE,
+    UNIQUE KEY unique_budget_alert (budget_id, period, threshold)
+);
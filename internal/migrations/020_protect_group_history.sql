@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS split_write_offs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    split_id INT NOT NULL,
+    debtor_id INT NOT NULL,
+    creditor_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    written_off_by INT NOT NULL,
+    reason VARCHAR(255) DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_write_off_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id),
+    CONSTRAINT fk_write_off_debtor FOREIGN KEY (debtor_id) REFERENCES users(id),
+    CONSTRAINT fk_write_off_creditor FOREIGN KEY (creditor_id) REFERENCES users(id),
+    CONSTRAINT fk_write_off_user FOREIGN KEY (written_off_by) REFERENCES users(id),
+    INDEX idx_write_off_split (split_id)
+);
+
+-- deleting a group must never take its expenses, splits or credits with it
+ALTER TABLE group_expenses
+    DROP FOREIGN KEY fk_expense_group,
+    ADD CONSTRAINT fk_expense_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT;
+
+ALTER TABLE member_credits
+    DROP FOREIGN KEY fk_credit_group,
+    ADD CONSTRAINT fk_credit_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT;
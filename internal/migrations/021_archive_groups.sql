@@ -0,0 +1,19 @@
+ALTER TABLE groups
+    ADD COLUMN archived_at DATETIME NULL DEFAULT NULL,
+    ADD COLUMN archived_by INT NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_group_archived_by FOREIGN KEY (archived_by) REFERENCES users(id) ON DELETE SET NULL;
+
+-- group_id is deliberately not a foreign key so the record outlives the purged group
+CREATE TABLE IF NOT EXISTS group_purge_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    requested_by INT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    purge_after DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    status ENUM('pending', 'cancelled', 'completed') NOT NULL DEFAULT 'pending',
+    completed_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_purge_requester FOREIGN KEY (requested_by) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_purge_group_status (group_id, status)
+);
@@ -0,0 +1,7 @@
+-- admin rights now come from group_members.role, so every owner must hold it
+INSERT IGNORE INTO group_members (group_id, user_id, role)
+SELECT id, created_by, 'admin' FROM groups;
+
+UPDATE group_members gm
+JOIN groups g ON g.id = gm.group_id AND g.created_by = gm.user_id
+SET gm.role = 'admin';
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS group_settings (
+    group_id INT PRIMARY KEY,
+    add_expense_policy ENUM('members', 'admins') NOT NULL DEFAULT 'members',
+    edit_expense_policy ENUM('payer_only', 'admins', 'members') NOT NULL DEFAULT 'payer_only',
+    invite_policy ENUM('admins', 'members') NOT NULL DEFAULT 'admins',
+    approval_threshold DECIMAL(18, 2) NULL DEFAULT NULL,
+    updated_by INT NULL DEFAULT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_settings_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_settings_user FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- expenses above a group's approval threshold wait here and only become a
+-- group_expenses row, with its splits, once an admin approves them
+CREATE TABLE IF NOT EXISTS expense_approvals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    paid_by INT NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    category_id INT NULL DEFAULT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    expense_id INT NULL DEFAULT NULL,
+    decided_by INT NULL DEFAULT NULL,
+    decision_note VARCHAR(255) NULL DEFAULT NULL,
+    decided_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_approval_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_approval_payer FOREIGN KEY (paid_by) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_approval_category FOREIGN KEY (category_id) REFERENCES expense_categories(id) ON DELETE SET NULL,
+    CONSTRAINT fk_approval_expense FOREIGN KEY (expense_id) REFERENCES group_expenses(id) ON DELETE SET NULL,
+    CONSTRAINT fk_approval_decider FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_approval_group_status (group_id, status)
+);
@@ -0,0 +1,36 @@
+-- shareable join codes; unlike group_invitations they are not tied to an email
+-- and can be used more than once
+CREATE TABLE IF NOT EXISTS group_join_links (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    code VARCHAR(32) NOT NULL,
+    created_by INT NULL DEFAULT NULL,
+    max_uses INT NULL DEFAULT NULL,
+    uses INT NOT NULL DEFAULT 0,
+    expires_at DATETIME NULL DEFAULT NULL,
+    requires_approval BOOLEAN NOT NULL DEFAULT FALSE,
+    status ENUM('active', 'revoked', 'rotated') NOT NULL DEFAULT 'active',
+    revoked_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_join_link_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_join_link_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_join_link_code (code),
+    INDEX idx_join_link_group (group_id, status)
+);
+
+-- users who joined through a link that requires approval wait here for an admin
+CREATE TABLE IF NOT EXISTS group_join_requests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    user_id INT NOT NULL,
+    link_id INT NULL DEFAULT NULL,
+    status ENUM('pending', 'approved', 'rejected') NOT NULL DEFAULT 'pending',
+    decided_by INT NULL DEFAULT NULL,
+    decided_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_join_request_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_join_request_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_join_request_link FOREIGN KEY (link_id) REFERENCES group_join_links(id) ON DELETE SET NULL,
+    CONSTRAINT fk_join_request_decider FOREIGN KEY (decided_by) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_join_request_group_status (group_id, status)
+);
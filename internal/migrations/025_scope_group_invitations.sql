@@ -0,0 +1,5 @@
+-- an email may be invited to any number of groups, but only once per group
+ALTER TABLE group_invitations
+    DROP INDEX email,
+    ADD UNIQUE KEY unique_group_invite_email (group_id, email),
+    ADD COLUMN allow_any_email BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- invitees can now decline; the row is kept so the inviter can see it
+ALTER TABLE group_invitations
+    MODIFY COLUMN status ENUM('pending', 'accepted', 'expired', 'revoked', 'declined') DEFAULT 'pending',
+    ADD COLUMN responded_at DATETIME NULL DEFAULT NULL;
@@ -0,0 +1,27 @@
+-- public groups can be found and asked to join without an invitation
+ALTER TABLE groups
+    ADD COLUMN is_public BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- registered users can be invited by username; those invites carry no token and
+-- are answered from the in-app inbox. Accepted invites are now kept as history.
+ALTER TABLE group_invitations
+    ADD COLUMN channel ENUM('email', 'username') NOT NULL DEFAULT 'email',
+    ADD COLUMN invited_user_id INT NULL DEFAULT NULL,
+    ADD CONSTRAINT fk_invite_invited_user FOREIGN KEY (invited_user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+ALTER TABLE group_join_requests
+    ADD COLUMN source ENUM('link', 'public') NOT NULL DEFAULT 'link';
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    message VARCHAR(255) NOT NULL,
+    group_id INT NULL DEFAULT NULL,
+    reference_id INT NULL DEFAULT NULL,
+    read_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_notification_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_notification_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    INDEX idx_notification_user_read (user_id, read_at)
+);
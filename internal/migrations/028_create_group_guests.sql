@@ -0,0 +1,40 @@
+-- guests stand in for people who have no account yet. They only have a display
+-- name and an optional email, and are merged into the real user once someone
+-- with that email joins the group.
+CREATE TABLE IF NOT EXISTS group_guests (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    group_id INT NOT NULL,
+    display_name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NULL DEFAULT NULL,
+    created_by INT NULL DEFAULT NULL,
+    merged_into_user_id INT NULL DEFAULT NULL,
+    merged_at DATETIME NULL DEFAULT NULL,
+    removed_at DATETIME NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_guest_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT,
+    CONSTRAINT fk_guest_creator FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_guest_merged_user FOREIGN KEY (merged_into_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY unique_group_guest_email (group_id, email)
+);
+
+-- a split is owed either by a member or by a guest. Merged guest splits keep
+-- guest_id so their origin stays visible.
+ALTER TABLE group_expense_splits
+    MODIFY owed_by INT NULL DEFAULT NULL,
+    ADD COLUMN guest_id INT NULL DEFAULT NULL AFTER owed_by,
+    ADD CONSTRAINT fk_split_guest FOREIGN KEY (guest_id) REFERENCES group_guests(id);
+
+-- guests pay outside the app, so their repayments are recorded by hand
+CREATE TABLE IF NOT EXISTS guest_split_payments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    split_id INT NOT NULL,
+    guest_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    recorded_by INT NOT NULL,
+    note VARCHAR(255) NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_guest_payment_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_guest_payment_guest FOREIGN KEY (guest_id) REFERENCES group_guests(id),
+    CONSTRAINT fk_guest_payment_user FOREIGN KEY (recorded_by) REFERENCES users(id),
+    INDEX idx_guest_payment_split (split_id)
+);
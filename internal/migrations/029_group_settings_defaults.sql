@@ -0,0 +1,34 @@
+-- group defaults that shape new expenses and reminders, alongside the permission settings
+ALTER TABLE group_settings
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'NGN' AFTER approval_threshold,
+    ADD COLUMN default_category_id INT NULL DEFAULT NULL AFTER currency,
+    ADD COLUMN allow_self_settle BOOLEAN NOT NULL DEFAULT FALSE AFTER default_category_id,
+    ADD COLUMN reminder_frequency ENUM('off', 'daily', 'weekly', 'monthly') NOT NULL DEFAULT 'daily' AFTER allow_self_settle,
+    ADD CONSTRAINT fk_settings_category FOREIGN KEY (default_category_id) REFERENCES expense_categories(id) ON DELETE SET NULL;
+
+-- members without a row split with weight 1
+CREATE TABLE IF NOT EXISTS group_split_weights (
+    group_id INT NOT NULL,
+    user_id INT NOT NULL,
+    weight DECIMAL(8, 2) NOT NULL,
+    PRIMARY KEY (group_id, user_id),
+    CONSTRAINT fk_weight_group FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+    CONSTRAINT fk_weight_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- splits members settled between themselves outside the wallet, where the group allows it
+CREATE TABLE IF NOT EXISTS offline_split_payments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    split_id INT NOT NULL,
+    payer_id INT NOT NULL,
+    payee_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    recorded_by INT NOT NULL,
+    note VARCHAR(255) NULL DEFAULT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_offline_payment_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id) ON DELETE CASCADE,
+    CONSTRAINT fk_offline_payment_payer FOREIGN KEY (payer_id) REFERENCES users(id),
+    CONSTRAINT fk_offline_payment_payee FOREIGN KEY (payee_id) REFERENCES users(id),
+    CONSTRAINT fk_offline_payment_user FOREIGN KEY (recorded_by) REFERENCES users(id),
+    INDEX idx_offline_payment_split (split_id)
+);
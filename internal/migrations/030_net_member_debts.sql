@@ -0,0 +1,22 @@
+-- debts running both ways between two members cancel out before anyone has to
+-- pay. Every split reduced that way keeps a record of how much was offset.
+CREATE TABLE IF NOT EXISTS split_offsets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    split_id INT NOT NULL,
+    debtor_id INT NOT NULL,
+    creditor_id INT NOT NULL,
+    amount DECIMAL(18, 2) NOT NULL,
+    recorded_by INT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_offset_split FOREIGN KEY (split_id) REFERENCES group_expense_splits(id),
+    CONSTRAINT fk_offset_debtor FOREIGN KEY (debtor_id) REFERENCES users(id),
+    CONSTRAINT fk_offset_creditor FOREIGN KEY (creditor_id) REFERENCES users(id),
+    CONSTRAINT fk_offset_user FOREIGN KEY (recorded_by) REFERENCES users(id),
+    INDEX idx_offset_split (split_id)
+);
+
+-- what a guest owes can be written off by the member it is owed to
+ALTER TABLE split_write_offs
+    MODIFY debtor_id INT NULL DEFAULT NULL,
+    ADD COLUMN guest_id INT NULL DEFAULT NULL AFTER debtor_id,
+    ADD CONSTRAINT fk_write_off_guest FOREIGN KEY (guest_id) REFERENCES group_guests(id);
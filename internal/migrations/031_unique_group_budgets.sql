@@ -0,0 +1,8 @@
+-- a group has at most one budget per category, and one overall budget. A NULL
+-- category_id would slip past a plain unique key, so it is keyed as 0 instead.
+DELETE b FROM group_budgets b
+JOIN group_budgets newer ON newer.group_id = b.group_id AND newer.category_id <=> b.category_id AND newer.id > b.id;
+
+ALTER TABLE group_budgets
+    ADD COLUMN category_key INT AS (COALESCE(category_id, 0)) STORED,
+    ADD UNIQUE KEY unique_group_budget (group_id, category_key);
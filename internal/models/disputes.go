@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type Dispute struct {
+	ID                int             `json:"id,omitempty" db:"id,omitempty"`
+	SubjectType       string          `json:"subject_type,omitempty" db:"subject_type,omitempty"`
+	SubjectID         int             `json:"subject_id,omitempty" db:"subject_id,omitempty"`
+	OpenedBy          int             `json:"opened_by,omitempty" db:"opened_by,omitempty"`
+	CounterpartyID    sql.NullInt64   `json:"counterparty_id,omitempty" db:"counterparty_id,omitempty"`
+	GroupID           sql.NullInt64   `json:"group_id,omitempty" db:"group_id,omitempty"`
+	SplitID           sql.NullInt64   `json:"split_id,omitempty" db:"split_id,omitempty"`
+	Amount            decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	Reason            string          `json:"reason,omitempty" db:"reason,omitempty"`
+	Evidence          sql.NullString  `json:"evidence,omitempty" db:"evidence,omitempty"`
+	Status            string          `json:"status,omitempty" db:"status,omitempty"`
+	Response          sql.NullString  `json:"response,omitempty" db:"response,omitempty"`
+	RespondedAt       sql.NullString  `json:"responded_at,omitempty" db:"responded_at,omitempty"`
+	ResolutionNote    sql.NullString  `json:"resolution_note,omitempty" db:"resolution_note,omitempty"`
+	ResolvedBy        sql.NullInt64   `json:"resolved_by,omitempty" db:"resolved_by,omitempty"`
+	ResolvedAt        sql.NullString  `json:"resolved_at,omitempty" db:"resolved_at,omitempty"`
+	ReversalPaymentID sql.NullInt64   `json:"reversal_payment_id,omitempty" db:"reversal_payment_id,omitempty"`
+	CreatedAt         sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt         sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
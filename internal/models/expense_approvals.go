@@ -0,0 +1,22 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type ExpenseApproval struct {
+	ID           int             `json:"id,omitempty" db:"id,omitempty"`
+	GroupID      int             `json:"group_id,omitempty" db:"group_id,omitempty"`
+	PaidBy       int             `json:"paid_by,omitempty" db:"paid_by,omitempty"`
+	Description  string          `json:"description,omitempty" db:"description,omitempty"`
+	CategoryID   sql.NullInt64   `json:"category_id,omitempty" db:"category_id,omitempty"`
+	Amount       decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	Status       string          `json:"status,omitempty" db:"status,omitempty"`
+	ExpenseID    sql.NullInt64   `json:"expense_id,omitempty" db:"expense_id,omitempty"`
+	DecidedBy    sql.NullInt64   `json:"decided_by,omitempty" db:"decided_by,omitempty"`
+	DecisionNote sql.NullString  `json:"decision_note,omitempty" db:"decision_note,omitempty"`
+	DecidedAt    sql.NullString  `json:"decided_at,omitempty" db:"decided_at,omitempty"`
+	CreatedAt    sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
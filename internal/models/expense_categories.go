@@ -0,0 +1,13 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type ExpenseCategory struct {
+	ID        int            `json:"id,omitempty" db:"id,omitempty"`
+	GroupID   sql.NullInt64  `json:"group_id,omitempty" db:"group_id,omitempty"`
+	Name      string         `json:"name,omitempty" db:"name,omitempty"`
+	CreatedBy sql.NullInt64  `json:"created_by,omitempty" db:"created_by,omitempty"`
+	CreatedAt sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
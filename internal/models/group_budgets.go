@@ -0,0 +1,17 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type GroupBudget struct {
+	ID         int             `json:"id,omitempty" db:"id,omitempty"`
+	GroupID    int             `json:"group_id,omitempty" db:"group_id,omitempty"`
+	CategoryID sql.NullInt64   `json:"category_id,omitempty" db:"category_id,omitempty"`
+	Amount     decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	CreatedBy  int             `json:"created_by,omitempty" db:"created_by,omitempty"`
+	CreatedAt  sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt  sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
@@ -7,10 +7,12 @@ import (
 )
 
 type GroupExpenseSplit struct {
-	ID         int             `json:"id,omitempty" db:"id,omitempty"`
-	ExpenseID  int             `json:"expense_id,omitempty" db:"expense_id,omitempty"`
-	OwedBy     int             `json:"owed_by,omitempty" db:"owed_by,omitempty"`
-	AmountOwed decimal.Decimal `json:"amount_owed,omitempty" db:"amount_owed,omitempty"`
-	IsSettled  bool            `json:"is_settled,omitempty" db:"is_settled,omitempty"`
-	CreatedAt  sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	ID          int             `json:"id,omitempty" db:"id,omitempty"`
+	ExpenseID   int             `json:"expense_id,omitempty" db:"expense_id,omitempty"`
+	OwedBy      int             `json:"owed_by,omitempty" db:"owed_by,omitempty"`
+	GuestID     int             `json:"guest_id,omitempty" db:"guest_id,omitempty"`
+	ShareAmount decimal.Decimal `json:"share_amount,omitempty" db:"share_amount,omitempty"`
+	AmountOwed  decimal.Decimal `json:"amount_owed,omitempty" db:"amount_owed,omitempty"`
+	IsSettled   bool            `json:"is_settled,omitempty" db:"is_settled,omitempty"`
+	CreatedAt   sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
 }
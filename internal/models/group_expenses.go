@@ -11,6 +11,7 @@ type GroupExpense struct {
 	GroupID     int             `json:"group_id,omitempty" db:"group_id,omitempty"`
 	PaidBy      int             `json:"paid_by,omitempty" db:"paid_by,omitempty"`
 	Description string          `json:"description,omitempty" db:"description,omitempty"`
+	CategoryID  sql.NullInt64   `json:"category_id,omitempty" db:"category_id,omitempty"`
 	Amount      decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
 	CreatedAt   sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
 }
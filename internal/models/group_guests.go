@@ -0,0 +1,29 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type GroupGuest struct {
+	ID               int             `json:"id,omitempty" db:"id,omitempty"`
+	GroupID          int             `json:"group_id,omitempty" db:"group_id,omitempty"`
+	DisplayName      string          `json:"display_name,omitempty" db:"display_name,omitempty"`
+	Email            sql.NullString  `json:"email,omitempty" db:"email,omitempty"`
+	CreatedBy        sql.NullInt64   `json:"created_by,omitempty" db:"created_by,omitempty"`
+	MergedIntoUserID sql.NullInt64   `json:"merged_into_user_id,omitempty" db:"merged_into_user_id,omitempty"`
+	MergedAt         sql.NullString  `json:"merged_at,omitempty" db:"merged_at,omitempty"`
+	Outstanding      decimal.Decimal `json:"outstanding" db:"-"`
+	CreatedAt        sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
+
+type GuestSplitPayment struct {
+	ID         int             `json:"id,omitempty" db:"id,omitempty"`
+	SplitID    int             `json:"split_id,omitempty" db:"split_id,omitempty"`
+	GuestID    int             `json:"guest_id,omitempty" db:"guest_id,omitempty"`
+	Amount     decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	RecordedBy int             `json:"recorded_by,omitempty" db:"recorded_by,omitempty"`
+	Note       sql.NullString  `json:"note,omitempty" db:"note,omitempty"`
+	CreatedAt  sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
@@ -3,12 +3,16 @@ package models
 import "database/sql"
 
 type GroupInvitation struct {
-	ID        int            `json:"id,omitempty" db:"id,omitempty"`
-	GroupID   int            `json:"group_id,omitempty" db:"group_id,omitempty"`
-	Email     string         `json:"email,omitempty" db:"email,omitempty"`
-	Token     string         `json:"token,omitempty" db:"token,omitempty"`
-	Status    string         `json:"status,omitempty" db:"status,omitempty"`
-	InvitedBy int            `json:"invited_by,omitempty" db:"invited_by,omitempty"`
-	ExpiresAt sql.NullString `json:"expires_at,omitempty" db:"expires_at,omitempty"`
-	CreatedAt sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+	ID            int            `json:"id,omitempty" db:"id,omitempty"`
+	GroupID       int            `json:"group_id,omitempty" db:"group_id,omitempty"`
+	Email         string         `json:"email,omitempty" db:"email,omitempty"`
+	Token         string         `json:"token,omitempty" db:"token,omitempty"`
+	Status        string         `json:"status,omitempty" db:"status,omitempty"`
+	AllowAnyEmail bool           `json:"allow_any_email" db:"allow_any_email"`
+	Channel       string         `json:"channel,omitempty" db:"channel,omitempty"`
+	InvitedUserID sql.NullInt64  `json:"invited_user_id,omitempty" db:"invited_user_id,omitempty"`
+	InvitedBy     int            `json:"invited_by,omitempty" db:"invited_by,omitempty"`
+	ExpiresAt     sql.NullString `json:"expires_at,omitempty" db:"expires_at,omitempty"`
+	RespondedAt   sql.NullString `json:"responded_at,omitempty" db:"responded_at,omitempty"`
+	CreatedAt     sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "database/sql"
+
+type GroupJoinLink struct {
+	ID               int            `json:"id,omitempty" db:"id,omitempty"`
+	GroupID          int            `json:"group_id,omitempty" db:"group_id,omitempty"`
+	Code             string         `json:"code,omitempty" db:"code,omitempty"`
+	URL              string         `json:"url,omitempty" db:"-"`
+	CreatedBy        sql.NullInt64  `json:"created_by,omitempty" db:"created_by,omitempty"`
+	MaxUses          sql.NullInt64  `json:"max_uses,omitempty" db:"max_uses,omitempty"`
+	Uses             int            `json:"uses" db:"uses"`
+	ExpiresAt        sql.NullString `json:"expires_at,omitempty" db:"expires_at,omitempty"`
+	RequiresApproval bool           `json:"requires_approval" db:"requires_approval"`
+	Status           string         `json:"status,omitempty" db:"status,omitempty"`
+	RevokedAt        sql.NullString `json:"revoked_at,omitempty" db:"revoked_at,omitempty"`
+	CreatedAt        sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "database/sql"
+
+type GroupJoinRequest struct {
+	ID        int            `json:"id,omitempty" db:"id,omitempty"`
+	GroupID   int            `json:"group_id,omitempty" db:"group_id,omitempty"`
+	UserID    int            `json:"user_id,omitempty" db:"user_id,omitempty"`
+	Username  string         `json:"username,omitempty" db:"username,omitempty"`
+	Email     string         `json:"email,omitempty" db:"email,omitempty"`
+	LinkID    sql.NullInt64  `json:"link_id,omitempty" db:"link_id,omitempty"`
+	Source    string         `json:"source,omitempty" db:"source,omitempty"`
+	Status    string         `json:"status,omitempty" db:"status,omitempty"`
+	DecidedBy sql.NullInt64  `json:"decided_by,omitempty" db:"decided_by,omitempty"`
+	DecidedAt sql.NullString `json:"decided_at,omitempty" db:"decided_at,omitempty"`
+	CreatedAt sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "database/sql"
+
+type GroupPurgeRequest struct {
+	ID          int            `json:"id,omitempty" db:"id,omitempty"`
+	GroupID     int            `json:"group_id,omitempty" db:"group_id,omitempty"`
+	RequestedBy int            `json:"requested_by,omitempty" db:"requested_by,omitempty"`
+	TokenHash   string         `json:"-" db:"token_hash,omitempty"`
+	PurgeAfter  string         `json:"purge_after,omitempty" db:"purge_after,omitempty"`
+	ExpiresAt   string         `json:"expires_at,omitempty" db:"expires_at,omitempty"`
+	Status      string         `json:"status,omitempty" db:"status,omitempty"`
+	CompletedAt sql.NullString `json:"completed_at,omitempty" db:"completed_at,omitempty"`
+	CreatedAt   sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
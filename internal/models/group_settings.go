@@ -0,0 +1,27 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type GroupSettings struct {
+	GroupID             int                 `json:"group_id,omitempty" db:"group_id,omitempty"`
+	AddExpensePolicy    string              `json:"add_expense_policy,omitempty" db:"add_expense_policy,omitempty"`
+	EditExpensePolicy   string              `json:"edit_expense_policy,omitempty" db:"edit_expense_policy,omitempty"`
+	InvitePolicy        string              `json:"invite_policy,omitempty" db:"invite_policy,omitempty"`
+	ApprovalThreshold   decimal.NullDecimal `json:"approval_threshold" db:"approval_threshold,omitempty"`
+	Currency            string              `json:"currency,omitempty" db:"currency,omitempty"`
+	DefaultCategoryID   sql.NullInt64       `json:"default_category_id" db:"default_category_id,omitempty"`
+	AllowSelfSettle     bool                `json:"allow_self_settle" db:"allow_self_settle"`
+	ReminderFrequency   string              `json:"reminder_frequency,omitempty" db:"reminder_frequency,omitempty"`
+	DefaultSplitWeights []SplitWeight       `json:"default_split_weights" db:"-"`
+	UpdatedBy           sql.NullInt64       `json:"updated_by,omitempty" db:"updated_by,omitempty"`
+	UpdatedAt           sql.NullString      `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
+
+type SplitWeight struct {
+	UserID int             `json:"user_id" db:"user_id"`
+	Weight decimal.Decimal `json:"weight" db:"weight"`
+}
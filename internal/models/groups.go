@@ -14,4 +14,6 @@ type Group struct {
 	TotalExpense decimal.Decimal `json:"total_expense,omitempty" db:"total_expense,omitempty"`
 	CreatedAt    sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
 	UpdatedAt    sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+	ArchivedAt   sql.NullString  `json:"archived_at,omitempty" db:"archived_at,omitempty"`
+	IsPublic     bool            `json:"is_public,omitempty" db:"is_public,omitempty"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+// MemberCredit is money a creditor owes back to a debtor after an overpaid split
+type MemberCredit struct {
+	ID              int             `json:"id,omitempty" db:"id,omitempty"`
+	GroupID         int             `json:"group_id,omitempty" db:"group_id,omitempty"`
+	FromUserID      int             `json:"from_user_id,omitempty" db:"from_user_id,omitempty"`
+	ToUserID        int             `json:"to_user_id,omitempty" db:"to_user_id,omitempty"`
+	Amount          decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	Remaining       decimal.Decimal `json:"remaining" db:"remaining,omitempty"`
+	SourcePaymentID sql.NullInt64   `json:"source_payment_id,omitempty" db:"source_payment_id,omitempty"`
+	CreatedAt       sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt       sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type MoneyRequest struct {
+	ID                  int             `json:"id,omitempty" db:"id,omitempty"`
+	RequesterID         int             `json:"requester_id,omitempty" db:"requester_id,omitempty"`
+	RecipientID         int             `json:"recipient_id,omitempty" db:"recipient_id,omitempty"`
+	GroupID             sql.NullInt64   `json:"group_id,omitempty" db:"group_id,omitempty"`
+	Amount              decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	Reason              string          `json:"reason,omitempty" db:"reason,omitempty"`
+	DueDate             sql.NullString  `json:"due_date,omitempty" db:"due_date,omitempty"`
+	Status              string          `json:"status,omitempty" db:"status,omitempty"`
+	DebitTransactionID  sql.NullInt64   `json:"debit_transaction_id,omitempty" db:"debit_transaction_id,omitempty"`
+	CreditTransactionID sql.NullInt64   `json:"credit_transaction_id,omitempty" db:"credit_transaction_id,omitempty"`
+	RespondedAt         sql.NullString  `json:"responded_at,omitempty" db:"responded_at,omitempty"`
+	CreatedAt           sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt           sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
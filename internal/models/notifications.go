@@ -0,0 +1,14 @@
+package models
+
+import "database/sql"
+
+type Notification struct {
+	ID          int            `json:"id,omitempty" db:"id,omitempty"`
+	UserID      int            `json:"user_id,omitempty" db:"user_id,omitempty"`
+	Type        string         `json:"type,omitempty" db:"type,omitempty"`
+	Message     string         `json:"message,omitempty" db:"message,omitempty"`
+	GroupID     sql.NullInt64  `json:"group_id,omitempty" db:"group_id,omitempty"`
+	ReferenceID sql.NullInt64  `json:"reference_id,omitempty" db:"reference_id,omitempty"`
+	ReadAt      sql.NullString `json:"read_at,omitempty" db:"read_at,omitempty"`
+	CreatedAt   sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
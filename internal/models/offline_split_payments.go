@@ -0,0 +1,18 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type OfflineSplitPayment struct {
+	ID         int             `json:"id,omitempty" db:"id,omitempty"`
+	SplitID    int             `json:"split_id,omitempty" db:"split_id,omitempty"`
+	PayerID    int             `json:"payer_id,omitempty" db:"payer_id,omitempty"`
+	PayeeID    int             `json:"payee_id,omitempty" db:"payee_id,omitempty"`
+	Amount     decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	RecordedBy int             `json:"recorded_by,omitempty" db:"recorded_by,omitempty"`
+	Note       sql.NullString  `json:"note,omitempty" db:"note,omitempty"`
+	CreatedAt  sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
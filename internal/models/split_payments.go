@@ -0,0 +1,22 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type SplitPayment struct {
+	ID                        int             `json:"id,omitempty" db:"id,omitempty"`
+	SplitID                   int             `json:"split_id,omitempty" db:"split_id,omitempty"`
+	PayerID                   int             `json:"payer_id,omitempty" db:"payer_id,omitempty"`
+	PayeeID                   int             `json:"payee_id,omitempty" db:"payee_id,omitempty"`
+	Amount                    decimal.Decimal `json:"amount,omitempty" db:"amount,omitempty"`
+	DebitTransactionID        int             `json:"debit_transaction_id,omitempty" db:"debit_transaction_id,omitempty"`
+	CreditTransactionID       int             `json:"credit_transaction_id,omitempty" db:"credit_transaction_id,omitempty"`
+	Status                    string          `json:"status,omitempty" db:"status,omitempty"`
+	RefundDebitTransactionID  sql.NullInt64   `json:"refund_debit_transaction_id,omitempty" db:"refund_debit_transaction_id,omitempty"`
+	RefundCreditTransactionID sql.NullInt64   `json:"refund_credit_transaction_id,omitempty" db:"refund_credit_transaction_id,omitempty"`
+	RefundedAt                sql.NullString  `json:"refunded_at,omitempty" db:"refunded_at,omitempty"`
+	CreatedAt                 sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+}
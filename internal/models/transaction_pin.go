@@ -0,0 +1,15 @@
+package models
+
+import "database/sql"
+
+type TransactionPin struct {
+	ID             int            `json:"id,omitempty" db:"id,omitempty"`
+	UserID         int            `json:"user_id,omitempty" db:"user_id,omitempty"`
+	PinHash        sql.NullString `json:"-" db:"pin_hash,omitempty"`
+	FailedAttempts int            `json:"failed_attempts,omitempty" db:"failed_attempts,omitempty"`
+	LockedUntil    sql.NullString `json:"locked_until,omitempty" db:"locked_until,omitempty"`
+	Otp            sql.NullString `json:"-" db:"otp,omitempty"`
+	OtpExpires     sql.NullString `json:"-" db:"otp_expires,omitempty"`
+	CreatedAt      sql.NullString `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt      sql.NullString `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
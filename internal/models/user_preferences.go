@@ -0,0 +1,17 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/shopspring/decimal"
+)
+
+type UserPreferences struct {
+	ID                   int             `json:"id,omitempty" db:"id,omitempty"`
+	UserID               int             `json:"user_id,omitempty" db:"user_id,omitempty"`
+	AutoSettleEnabled    bool            `json:"auto_settle_enabled" db:"auto_settle_enabled,omitempty"`
+	AutoSettleFloor      decimal.Decimal `json:"auto_settle_floor" db:"auto_settle_floor,omitempty"`
+	MonthlyDigestEnabled bool            `json:"monthly_digest_enabled" db:"monthly_digest_enabled,omitempty"`
+	CreatedAt            sql.NullString  `json:"created_at,omitempty" db:"created_at,omitempty"`
+	UpdatedAt            sql.NullString  `json:"updated_at,omitempty" db:"updated_at,omitempty"`
+}
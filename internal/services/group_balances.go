@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"qiyana_paybuddy/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrNotWriteOffAuthorised = errors.New("only the member who is owed can write off this debt")
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// OutstandingDebt is an unsettled split owed to a member of a group by another
+// member, or by a guest when GuestID is set and DebtorID is 0
+type OutstandingDebt struct {
+	SplitID     int             `json:"split_id"`
+	ExpenseID   int             `json:"expense_id"`
+	Description string          `json:"description"`
+	DebtorID    int             `json:"debtor_id"`
+	GuestID     int             `json:"guest_id,omitempty"`
+	Debtor      string          `json:"debtor"`
+	CreditorID  int             `json:"creditor_id"`
+	Creditor    string          `json:"creditor"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// DebtResolution is what ResolveMemberDebts did, and what it could not do
+type DebtResolution struct {
+	Offset     []OutstandingDebt
+	Settled    []*SplitSettlement
+	WrittenOff []OutstandingDebt
+	Unresolved []OutstandingDebt
+}
+
+// OutstandingDebts lists the unsettled splits in a group, including those owed
+// by guests. When userID is set only the splits that user owes or is owed are
+// returned. Inside a transaction the splits are locked until it ends.
+func OutstandingDebts(ctx context.Context, q queryer, groupID, userID int) ([]OutstandingDebt, error) {
+	query := `
+		SELECT s.id, e.id, e.description, COALESCE(s.owed_by, 0), COALESCE(s.guest_id, 0),
+			COALESCE(debtor.username, guest.display_name), e.paid_by, creditor.username, s.amount_owed
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		LEFT JOIN users debtor ON debtor.id = s.owed_by
+		LEFT JOIN group_guests guest ON guest.id = s.guest_id AND s.owed_by IS NULL
+		JOIN users creditor ON creditor.id = e.paid_by
+		WHERE e.group_id = ? AND s.is_settled = FALSE AND s.amount_owed > 0
+			AND (debtor.id IS NOT NULL OR guest.id IS NOT NULL)`
+	args := []interface{}{groupID}
+	if userID != 0 {
+		query += " AND (s.owed_by = ? OR e.paid_by = ?)"
+		args = append(args, userID, userID)
+	}
+	query += " ORDER BY e.created_at, s.id"
+	if _, ok := q.(*sql.Tx); ok {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to fetch outstanding debts")
+	}
+	defer rows.Close()
+
+	debts := make([]OutstandingDebt, 0)
+	for rows.Next() {
+		var d OutstandingDebt
+		if err := rows.Scan(&d.SplitID, &d.ExpenseID, &d.Description, &d.DebtorID, &d.GuestID, &d.Debtor, &d.CreditorID, &d.Creditor, &d.Amount); err != nil {
+			return nil, utils.ErrorHandler(err, "failed to read outstanding debts")
+		}
+		debts = append(debts, d)
+	}
+
+	return debts, rows.Err()
+}
+
+// OffsetDebts cancels out debts running both ways between the same two members,
+// oldest splits first, so only the difference is left to settle or write off.
+// debts must have been listed inside tx. Every split reduced is recorded against
+// actorID. It returns what is still owed and what was offset.
+func OffsetDebts(ctx context.Context, tx *sql.Tx, debts []OutstandingDebt, actorID int) ([]OutstandingDebt, []OutstandingDebt, error) {
+	type pair struct{ debtor, creditor int }
+	byPair := make(map[pair][]int)
+	for i, d := range debts {
+		if d.DebtorID != 0 {
+			byPair[pair{d.DebtorID, d.CreditorID}] = append(byPair[pair{d.DebtorID, d.CreditorID}], i)
+		}
+	}
+
+	var offset []OutstandingDebt
+	done := make(map[pair]bool)
+	for _, d := range debts {
+		owes, owed := pair{d.DebtorID, d.CreditorID}, pair{d.CreditorID, d.DebtorID}
+		if d.DebtorID == 0 || done[owes] || len(byPair[owed]) == 0 {
+			continue
+		}
+		done[owes], done[owed] = true, true
+
+		amount := decimal.Min(sumDebts(debts, byPair[owes]), sumDebts(debts, byPair[owed]))
+		for _, side := range [][]int{byPair[owes], byPair[owed]} {
+			left := amount
+			for _, i := range side {
+				if left.IsZero() {
+					break
+				}
+				take := decimal.Min(debts[i].Amount, left)
+				remaining := debts[i].Amount.Sub(take)
+
+				_, err := tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ?, is_settled = ? WHERE id = ?", remaining, remaining.IsZero(), debts[i].SplitID)
+				if err != nil {
+					return nil, nil, utils.ErrorHandler(err, "failed to offset split")
+				}
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO split_offsets (split_id, debtor_id, creditor_id, amount, recorded_by)
+					VALUES (?, ?, ?, ?, ?)
+				`, debts[i].SplitID, debts[i].DebtorID, debts[i].CreditorID, take, actorID)
+				if err != nil {
+					return nil, nil, utils.ErrorHandler(err, "failed to record offset")
+				}
+
+				cleared := debts[i]
+				cleared.Amount = take
+				offset = append(offset, cleared)
+				debts[i].Amount = remaining
+				left = left.Sub(take)
+			}
+		}
+	}
+
+	remaining := make([]OutstandingDebt, 0, len(debts))
+	for _, d := range debts {
+		if d.Amount.GreaterThan(decimal.Zero) {
+			remaining = append(remaining, d)
+		}
+	}
+	return remaining, offset, nil
+}
+
+func sumDebts(debts []OutstandingDebt, indexes []int) decimal.Decimal {
+	total := decimal.Zero
+	for _, i := range indexes {
+		total = total.Add(debts[i].Amount)
+	}
+	return total
+}
+
+// WriteOffSplit forgives whatever is still owed on a split, by a member or a
+// guest, and records who forgave it. Only the member owed may write a debt off.
+func WriteOffSplit(ctx context.Context, tx *sql.Tx, splitID, userID int, reason string) (*OutstandingDebt, error) {
+	debt := OutstandingDebt{SplitID: splitID}
+	err := tx.QueryRowContext(ctx, `
+		SELECT s.expense_id, COALESCE(s.owed_by, 0), COALESCE(s.guest_id, 0), e.paid_by, s.amount_owed
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.id = ? AND (s.owed_by IS NOT NULL OR s.guest_id IS NOT NULL) AND s.is_settled = FALSE FOR UPDATE
+	`, splitID).Scan(&debt.ExpenseID, &debt.DebtorID, &debt.GuestID, &debt.CreditorID, &debt.Amount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSplitNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving expense split")
+	}
+
+	if debt.CreditorID != userID {
+		return nil, ErrNotWriteOffAuthorised
+	}
+
+	// a guest split has no debtor, only the guest
+	var debtorID, guestID sql.NullInt64
+	if debt.DebtorID != 0 {
+		debtorID = sql.NullInt64{Int64: int64(debt.DebtorID), Valid: true}
+	} else {
+		guestID = sql.NullInt64{Int64: int64(debt.GuestID), Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO split_write_offs (split_id, debtor_id, guest_id, creditor_id, amount, written_off_by, reason)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, splitID, debtorID, guestID, debt.CreditorID, debt.Amount, userID, reason)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to record write-off")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = 0, is_settled = TRUE WHERE id = ?", splitID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to mark split as written off")
+	}
+
+	return &debt, nil
+}
+
+// ResolveMemberDebts clears the outstanding debts of memberID in a group (or of
+// everyone when memberID is 0) on behalf of actorID before they leave. Debts
+// running both ways between two members are offset against each other first.
+// With settleUp, debts the actor owes are paid from their wallet; with writeOff,
+// debts the actor is owed are forgiven. Debts between other members are never
+// touched, even by an admin, and are returned as unresolved.
+func ResolveMemberDebts(ctx context.Context, tx *sql.Tx, groupID, memberID, actorID int, settleUp, writeOff bool, reason string) (*DebtResolution, error) {
+	debts, err := OutstandingDebts(ctx, tx, groupID, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	debts, offset, err := OffsetDebts(ctx, tx, debts, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	resolution := &DebtResolution{Offset: offset}
+	for _, d := range debts {
+		switch {
+		case settleUp && d.DebtorID == actorID:
+			settlement, err := SettleSplit(ctx, tx, d.SplitID, actorID, d.Amount, false)
+			if err != nil {
+				return nil, err
+			}
+			resolution.Settled = append(resolution.Settled, settlement)
+		case writeOff && d.CreditorID == actorID:
+			if _, err := WriteOffSplit(ctx, tx, d.SplitID, actorID, reason); err != nil {
+				return nil, err
+			}
+			resolution.WrittenOff = append(resolution.WrittenOff, d)
+		default:
+			resolution.Unresolved = append(resolution.Unresolved, d)
+		}
+	}
+
+	return resolution, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"qiyana_paybuddy/pkg/utils"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrRequestNotFound     = errors.New("money request not found")
+	ErrRequestNotPending   = errors.New("money request is no longer pending")
+	ErrNotRequestRecipient = errors.New("this money request was not sent to you")
+	ErrNotRequestRequester = errors.New("only the member who sent this request can cancel it")
+)
+
+// MoneyRequestPayment is the outcome of accepting a money request
+type MoneyRequestPayment struct {
+	RequestID   int
+	RequesterID int
+	RecipientID int
+	Amount      decimal.Decimal
+	Reason      string
+	Transfer    *Transfer
+}
+
+type pendingRequest struct {
+	requesterID int
+	recipientID int
+	groupID     sql.NullInt64
+	amount      decimal.Decimal
+	reason      string
+}
+
+// lockPendingRequest loads a money request for update and checks it can still be acted on
+func lockPendingRequest(ctx context.Context, tx *sql.Tx, requestID int) (*pendingRequest, error) {
+	var status string
+	req := &pendingRequest{}
+	err := tx.QueryRowContext(ctx, `
+		SELECT requester_id, recipient_id, group_id, amount, reason, status FROM money_requests WHERE id = ? FOR UPDATE
+	`, requestID).Scan(&req.requesterID, &req.recipientID, &req.groupID, &req.amount, &req.reason, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrRequestNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving money request")
+	}
+
+	if status != "pending" {
+		return nil, ErrRequestNotPending
+	}
+
+	return req, nil
+}
+
+// PayMoneyRequest pays a pending money request from the recipient's wallet to the requester
+func PayMoneyRequest(ctx context.Context, tx *sql.Tx, requestID, payerID int) (*MoneyRequestPayment, error) {
+	req, err := lockPendingRequest(ctx, tx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.recipientID != payerID {
+		return nil, ErrNotRequestRecipient
+	}
+
+	transfer, err := TransferFunds(ctx, tx, TransferRequest{
+		FromUserID:        payerID,
+		ToUserID:          req.requesterID,
+		Amount:            req.amount,
+		Category:          "request",
+		ReferencePrefix:   "mreq",
+		DebitDescription:  fmt.Sprintf("Payment for money request #%d: %s", requestID, req.reason),
+		CreditDescription: fmt.Sprintf("Received payment for money request #%d: %s", requestID, req.reason),
+		RelatedType:       "money_request",
+		RelatedID:         int64(requestID),
+		GroupID:           req.groupID.Int64,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE money_requests
+		SET status = 'paid', debit_transaction_id = ?, credit_transaction_id = ?, responded_at = ?
+		WHERE id = ?
+	`, transfer.DebitTransactionID, transfer.CreditTransactionID, time.Now().Format("2006-01-02 15:04:05"), requestID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to mark money request as paid")
+	}
+
+	return &MoneyRequestPayment{
+		RequestID:   requestID,
+		RequesterID: req.requesterID,
+		RecipientID: req.recipientID,
+		Amount:      req.amount,
+		Reason:      req.reason,
+		Transfer:    transfer,
+	}, nil
+}
+
+// CloseMoneyRequest marks a pending request as declined by its recipient or
+// cancelled by its requester, depending on status.
+func CloseMoneyRequest(ctx context.Context, tx *sql.Tx, requestID, userID int, status string) error {
+	req, err := lockPendingRequest(ctx, tx, requestID)
+	if err != nil {
+		return err
+	}
+
+	if status == "declined" && req.recipientID != userID {
+		return ErrNotRequestRecipient
+	}
+	if status == "cancelled" && req.requesterID != userID {
+		return ErrNotRequestRequester
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE money_requests SET status = ?, responded_at = ? WHERE id = ?",
+		status, time.Now().Format("2006-01-02 15:04:05"), requestID)
+	if err != nil {
+		return utils.ErrorHandler(err, "failed to update money request")
+	}
+
+	return nil
+}
+
+// NotifyMoneyRequestResponse emails the requester that a recipient paid or declined their request
+func NotifyMoneyRequestResponse(ctx context.Context, db *sql.DB, requestID int, status string) {
+	var requesterEmail, requesterName, recipientName, reason string
+	var amount decimal.Decimal
+	err := db.QueryRowContext(ctx, `
+		SELECT rq.email, rq.first_name, rc.username, r.reason, r.amount
+		FROM money_requests r
+		JOIN users rq ON rq.id = r.requester_id
+		JOIN users rc ON rc.id = r.recipient_id
+		WHERE r.id = ?
+	`, requestID).Scan(&requesterEmail, &requesterName, &recipientName, &reason, &amount)
+	if err != nil {
+		utils.Logger.Errorf("failed to load money request %d for notification: %v", requestID, err)
+		return
+	}
+
+	go func() {
+		if err := utils.SendMoneyRequestResponseEmail(requesterEmail, requesterName, recipientName, amount.StringFixed(2), reason, status); err != nil {
+			utils.Logger.Errorf("failed to send money request response email to %s: %v", requesterEmail, err)
+		}
+	}()
+}
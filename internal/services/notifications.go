@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"qiyana_paybuddy/internal/models"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Notify stores an in-app notification. Pass the caller's transaction so the
+// notification only appears if the action it describes is committed.
+func Notify(ctx context.Context, db execer, n models.Notification) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, type, message, group_id, reference_id) VALUES (?, ?, ?, ?, ?)
+	`, n.UserID, n.Type, n.Message, n.GroupID, n.ReferenceID)
+	return err
+}
+
+// NotifyGroupAdmins sends the same notification to every admin of the group
+func NotifyGroupAdmins(ctx context.Context, db execer, groupID int, kind, message string, referenceID sql.NullInt64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO notifications (user_id, type, message, group_id, reference_id)
+		SELECT user_id, ?, ?, group_id, ? FROM group_members WHERE group_id = ? AND role = 'admin'
+	`, kind, message, referenceID, groupID)
+	return err
+}
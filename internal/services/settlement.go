@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"qiyana_paybuddy/pkg/utils"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be greater than 0")
+	ErrInsufficientFunds = errors.New("insufficient funds in wallet, please fund wallet")
+	ErrWalletNotFound    = errors.New("error fetching wallet balance")
+	ErrSplitNotFound     = errors.New("expense split not found")
+	ErrExpenseNotFound   = errors.New("expense not found")
+	ErrNotSplitDebtor    = errors.New("this expense split does not belong to you")
+	ErrOverpayment       = errors.New("amount exceeds the amount owed on this split")
+	ErrWalletCurrency    = errors.New("wallets hold NGN, so splits in this group's currency must be settled outside the wallet")
+
+	ErrPaymentNotFound        = errors.New("split payment not found")
+	ErrPaymentAlreadyRefunded = errors.New("split payment already refunded")
+	ErrNotPaymentCreditor     = errors.New("only the member who received this payment can refund it")
+	ErrCreditAlreadyApplied   = errors.New("the overpayment credit from this payment has already been used")
+)
+
+// TransferRequest describes a wallet-to-wallet movement of funds
+type TransferRequest struct {
+	FromUserID        int
+	ToUserID          int
+	Amount            decimal.Decimal
+	Category          string
+	ReferencePrefix   string
+	DebitDescription  string
+	CreditDescription string
+	// DebitReversalOf and CreditReversalOf link each side to the transaction it reverses
+	DebitReversalOf  int64
+	CreditReversalOf int64
+	// RelatedType and RelatedID point both sides at the record that caused the transfer
+	RelatedType string
+	RelatedID   int64
+	GroupID     int64
+}
+
+// Transfer holds the double-entry rows written for a TransferRequest
+type Transfer struct {
+	DebitTransactionID  int64
+	CreditTransactionID int64
+	DebitReference      string
+	CreditReference     string
+	PayerBalance        decimal.Decimal
+}
+
+// SplitSettlement is the outcome of paying towards an expense split
+type SplitSettlement struct {
+	PaymentID   int64
+	SplitID     int
+	ExpenseID   int
+	GroupID     int
+	CreditorID  int
+	AmountPaid  decimal.Decimal
+	Remaining   decimal.Decimal
+	IsFullyPaid bool
+	// Credit is the overpaid amount the creditor now owes back to the payer
+	Credit   decimal.Decimal
+	Transfer *Transfer
+}
+
+func lockWalletBalance(ctx context.Context, tx *sql.Tx, userID int) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := tx.QueryRowContext(ctx, "SELECT balance FROM wallets WHERE user_id = ? FOR UPDATE", userID).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return decimal.Zero, ErrWalletNotFound
+		}
+		return decimal.Zero, utils.ErrorHandler(err, "failed to fetch wallet")
+	}
+	return balance, nil
+}
+
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func lockOrder(a, b int) []int {
+	if a < b {
+		return []int{a, b}
+	}
+	return []int{b, a}
+}
+
+// TransferFunds debits one wallet, credits another and records both sides in
+// transactions. It must run inside the caller's database transaction.
+func TransferFunds(ctx context.Context, tx *sql.Tx, req TransferRequest) (*Transfer, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrInvalidAmount
+	}
+
+	// lock both wallets in a fixed order so opposite transfers cannot deadlock
+	balances := map[int]decimal.Decimal{}
+	for _, id := range lockOrder(req.FromUserID, req.ToUserID) {
+		balance, err := lockWalletBalance(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		balances[id] = balance
+	}
+
+	payerBalance := balances[req.FromUserID]
+	if payerBalance.LessThan(req.Amount) {
+		return nil, ErrInsufficientFunds
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE wallets SET balance = balance - ? WHERE user_id = ?", req.Amount, req.FromUserID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to update payer wallet")
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE wallets SET balance = balance + ?, last_funded_at = ? WHERE user_id = ?",
+		req.Amount, time.Now().Format("2006-01-02 15:04:05"), req.ToUserID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to update receiver wallet")
+	}
+
+	transfer := &Transfer{
+		DebitReference:  fmt.Sprintf("%s-%s", req.ReferencePrefix, utils.GenerateRandomString(10)),
+		CreditReference: fmt.Sprintf("%s-%s", req.ReferencePrefix, utils.GenerateRandomString(10)),
+		PayerBalance:    payerBalance.Sub(req.Amount),
+	}
+
+	relatedType := sql.NullString{String: req.RelatedType, Valid: req.RelatedType != ""}
+
+	// Payer transaction (DEBIT)
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description, reversal_of,
+			related_type, related_id, counterparty_user_id, group_id)
+		VALUES (?, 'debit', ?, ?, 'success', ?, ?, ?, ?, ?, ?, ?)
+	`, req.FromUserID, req.Category, req.Amount, transfer.DebitReference, req.DebitDescription, nullableID(req.DebitReversalOf),
+		relatedType, nullableID(req.RelatedID), req.ToUserID, nullableID(req.GroupID))
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to record payer transaction")
+	}
+	transfer.DebitTransactionID, _ = res.LastInsertId()
+
+	// Recipient transaction (CREDIT)
+	res, err = tx.ExecContext(ctx, `
+		INSERT INTO transactions (user_id, transaction_type, category, amount, status, reference, description, reversal_of,
+			related_type, related_id, counterparty_user_id, group_id)
+		VALUES (?, 'credit', ?, ?, 'success', ?, ?, ?, ?, ?, ?, ?)
+	`, req.ToUserID, req.Category, req.Amount, transfer.CreditReference, req.CreditDescription, nullableID(req.CreditReversalOf),
+		relatedType, nullableID(req.RelatedID), req.FromUserID, nullableID(req.GroupID))
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to record recipient transaction")
+	}
+	transfer.CreditTransactionID, _ = res.LastInsertId()
+
+	return transfer, nil
+}
+
+// SettleSplit pays amount from the debtor's wallet towards an unsettled split
+// and credits the member who paid the expense. Paying more than is owed fails
+// with ErrOverpayment unless allowOverpayment is set, in which case the excess
+// is stored as a member credit that offsets future splits between the pair.
+func SettleSplit(ctx context.Context, tx *sql.Tx, splitID, payerID int, amount decimal.Decimal, allowOverpayment bool) (*SplitSettlement, error) {
+	if amount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrInvalidAmount
+	}
+
+	var owedBy int
+	settlement := &SplitSettlement{SplitID: splitID, AmountPaid: amount, Credit: decimal.Zero}
+
+	var amountOwed decimal.Decimal
+	err := tx.QueryRowContext(ctx, `
+		SELECT expense_id, COALESCE(owed_by, 0), amount_owed FROM group_expense_splits
+		WHERE id = ? AND is_settled = FALSE FOR UPDATE
+	`, splitID).Scan(&settlement.ExpenseID, &owedBy, &amountOwed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSplitNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving expense split")
+	}
+
+	if owedBy != payerID {
+		return nil, ErrNotSplitDebtor
+	}
+
+	if amount.GreaterThan(amountOwed) && !allowOverpayment {
+		return nil, ErrOverpayment
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT group_id, paid_by FROM group_expenses WHERE id = ?", settlement.ExpenseID).
+		Scan(&settlement.GroupID, &settlement.CreditorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrExpenseNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving expense")
+	}
+
+	var currency string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE((SELECT currency FROM group_settings WHERE group_id = ?), 'NGN')", settlement.GroupID).Scan(&currency)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "error retrieving group currency")
+	}
+	if currency != "NGN" {
+		return nil, ErrWalletCurrency
+	}
+
+	transfer, err := TransferFunds(ctx, tx, TransferRequest{
+		FromUserID:        payerID,
+		ToUserID:          settlement.CreditorID,
+		Amount:            amount,
+		Category:          "split",
+		ReferencePrefix:   "splt",
+		DebitDescription:  fmt.Sprintf("Payment for split #%d", splitID),
+		CreditDescription: fmt.Sprintf("Received payment for split #%d", splitID),
+		RelatedType:       "split",
+		RelatedID:         int64(splitID),
+		GroupID:           int64(settlement.GroupID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	settlement.Transfer = transfer
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO split_payments (split_id, payer_id, payee_id, amount, debit_transaction_id, credit_transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, splitID, payerID, settlement.CreditorID, amount, transfer.DebitTransactionID, transfer.CreditTransactionID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to record split payment")
+	}
+	settlement.PaymentID, _ = res.LastInsertId()
+
+	settlement.IsFullyPaid = amount.GreaterThanOrEqual(amountOwed)
+	settlement.Remaining = decimal.Max(amountOwed.Sub(amount), decimal.Zero)
+
+	if amount.GreaterThan(amountOwed) {
+		settlement.Credit = amount.Sub(amountOwed)
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO member_credits (group_id, from_user_id, to_user_id, amount, remaining, source_payment_id)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, settlement.GroupID, settlement.CreditorID, payerID, settlement.Credit, settlement.Credit, settlement.PaymentID)
+		if err != nil {
+			return nil, utils.ErrorHandler(err, "failed to record overpayment credit")
+		}
+	}
+
+	if settlement.IsFullyPaid {
+		_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ?, is_settled = TRUE WHERE id = ?", 0, splitID)
+		if err != nil {
+			return nil, utils.ErrorHandler(err, "failed to mark split as settled")
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ? WHERE id = ?", settlement.Remaining, splitID)
+		if err != nil {
+			return nil, utils.ErrorHandler(err, "failed to update remaining amount")
+		}
+	}
+
+	return settlement, nil
+}
+
+// ApplyMemberCredits uses any outstanding credits the creditor owes the debtor
+// in a group to pay down a newly created split. It returns the amount applied.
+func ApplyMemberCredits(ctx context.Context, tx *sql.Tx, groupID, splitID, debtorID, creditorID int) (decimal.Decimal, error) {
+	applied := decimal.Zero
+
+	var amountOwed decimal.Decimal
+	err := tx.QueryRowContext(ctx, "SELECT amount_owed FROM group_expense_splits WHERE id = ? FOR UPDATE", splitID).Scan(&amountOwed)
+	if err != nil {
+		return applied, utils.ErrorHandler(err, "error retrieving expense split")
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, remaining FROM member_credits
+		WHERE group_id = ? AND from_user_id = ? AND to_user_id = ? AND remaining > 0
+		ORDER BY created_at ASC, id ASC
+		FOR UPDATE
+	`, groupID, creditorID, debtorID)
+	if err != nil {
+		return applied, utils.ErrorHandler(err, "error retrieving member credits")
+	}
+
+	type openCredit struct {
+		id        int
+		remaining decimal.Decimal
+	}
+
+	var credits []openCredit
+	for rows.Next() {
+		var c openCredit
+		if err := rows.Scan(&c.id, &c.remaining); err != nil {
+			rows.Close()
+			return applied, utils.ErrorHandler(err, "error reading member credits")
+		}
+		credits = append(credits, c)
+	}
+	rows.Close()
+
+	for _, c := range credits {
+		if amountOwed.LessThanOrEqual(decimal.Zero) {
+			break
+		}
+
+		use := decimal.Min(c.remaining, amountOwed)
+		if _, err := tx.ExecContext(ctx, "UPDATE member_credits SET remaining = remaining - ? WHERE id = ?", use, c.id); err != nil {
+			return applied, utils.ErrorHandler(err, "failed to apply member credit")
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO member_credit_applications (credit_id, split_id, amount) VALUES (?, ?, ?)", c.id, splitID, use); err != nil {
+			return applied, utils.ErrorHandler(err, "failed to record credit application")
+		}
+
+		amountOwed = amountOwed.Sub(use)
+		applied = applied.Add(use)
+	}
+
+	if applied.GreaterThan(decimal.Zero) {
+		_, err = tx.ExecContext(ctx, "UPDATE group_expense_splits SET amount_owed = ?, is_settled = ? WHERE id = ?",
+			amountOwed, amountOwed.LessThanOrEqual(decimal.Zero), splitID)
+		if err != nil {
+			return applied, utils.ErrorHandler(err, "failed to offset split with member credit")
+		}
+	}
+
+	return applied, nil
+}
+
+// SplitRefund is the outcome of reversing a split payment
+type SplitRefund struct {
+	PaymentID  int
+	SplitID    int
+	GroupID    int
+	PayerID    int
+	CreditorID int
+	Amount     decimal.Decimal
+	AmountOwed decimal.Decimal
+	Transfer   *Transfer
+}
+
+// RefundSplitPayment sends a completed split payment back from the creditor to
+// the payer, links the reversing transactions to the originals and reopens the split.
+func RefundSplitPayment(ctx context.Context, tx *sql.Tx, paymentID, creditorID int) (*SplitRefund, error) {
+	var status string
+	var debitID, creditID int64
+	refund := &SplitRefund{PaymentID: paymentID}
+
+	err := tx.QueryRowContext(ctx, `
+		SELECT split_id, payer_id, payee_id, amount, debit_transaction_id, credit_transaction_id, status
+		FROM split_payments WHERE id = ? FOR UPDATE
+	`, paymentID).Scan(&refund.SplitID, &refund.PayerID, &refund.CreditorID, &refund.Amount, &debitID, &creditID, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving split payment")
+	}
+
+	if refund.CreditorID != creditorID {
+		return nil, ErrNotPaymentCreditor
+	}
+
+	if status == "refunded" {
+		return nil, ErrPaymentAlreadyRefunded
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT e.group_id FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.id = ? FOR UPDATE
+	`, refund.SplitID).Scan(&refund.GroupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSplitNotFound
+		}
+		return nil, utils.ErrorHandler(err, "error retrieving expense split")
+	}
+
+	// an overpayment credit must be withdrawn so the split only reopens by what was owed
+	reopenBy := refund.Amount
+	var overpaymentID int
+	var creditAmount, creditRemaining decimal.Decimal
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, amount, remaining FROM member_credits WHERE source_payment_id = ? FOR UPDATE
+	`, paymentID).Scan(&overpaymentID, &creditAmount, &creditRemaining)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, utils.ErrorHandler(err, "error retrieving overpayment credit")
+	}
+	if err == nil {
+		if !creditRemaining.Equal(creditAmount) {
+			return nil, ErrCreditAlreadyApplied
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM member_credits WHERE id = ?", overpaymentID); err != nil {
+			return nil, utils.ErrorHandler(err, "failed to withdraw overpayment credit")
+		}
+		reopenBy = refund.Amount.Sub(creditAmount)
+	}
+
+	transfer, err := TransferFunds(ctx, tx, TransferRequest{
+		FromUserID:        refund.CreditorID,
+		ToUserID:          refund.PayerID,
+		Amount:            refund.Amount,
+		Category:          "refund",
+		ReferencePrefix:   "rfnd",
+		DebitDescription:  fmt.Sprintf("Refund of payment #%d for split #%d", paymentID, refund.SplitID),
+		CreditDescription: fmt.Sprintf("Refund received for split #%d", refund.SplitID),
+		DebitReversalOf:   creditID,
+		CreditReversalOf:  debitID,
+		RelatedType:       "split_payment",
+		RelatedID:         int64(paymentID),
+		GroupID:           int64(refund.GroupID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refund.Transfer = transfer
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE group_expense_splits SET amount_owed = amount_owed + ?, is_settled = FALSE WHERE id = ?
+	`, reopenBy, refund.SplitID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to reopen split")
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE split_payments
+		SET status = 'refunded', refunded_at = ?, refund_debit_transaction_id = ?, refund_credit_transaction_id = ?
+		WHERE id = ?
+	`, time.Now().Format("2006-01-02 15:04:05"), transfer.DebitTransactionID, transfer.CreditTransactionID, paymentID)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to mark payment as refunded")
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT amount_owed FROM group_expense_splits WHERE id = ?", refund.SplitID).Scan(&refund.AmountOwed)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to read reopened split")
+	}
+
+	return refund, nil
+}
+
+// NotifySplitPayment emails the creditor that a split payment has been received
+func NotifySplitPayment(ctx context.Context, db *sql.DB, payerID int, settlement *SplitSettlement) {
+	var payerName, receiverEmail, groupName string
+	db.QueryRowContext(ctx, "SELECT username FROM users WHERE id = ?", payerID).Scan(&payerName)
+	db.QueryRowContext(ctx, "SELECT email FROM users WHERE id = ?", settlement.CreditorID).Scan(&receiverEmail)
+	db.QueryRowContext(ctx, "SELECT name FROM groups WHERE id = ?", settlement.GroupID).Scan(&groupName)
+
+	go func() {
+		if err := utils.SendPaymentReceivedEmail(receiverEmail, payerName, settlement.AmountPaid.StringFixed(2), groupName, settlement.SplitID, time.Now()); err != nil {
+			utils.Logger.Errorf("failed to send payment received email to %s: %v", receiverEmail, err)
+		}
+	}()
+}
+
+// NotifySplitRefund emails both the payer and the creditor about a refunded split payment
+func NotifySplitRefund(ctx context.Context, db *sql.DB, refund *SplitRefund) {
+	var payerName, payerEmail, creditorName, creditorEmail, groupName string
+	db.QueryRowContext(ctx, "SELECT username, email FROM users WHERE id = ?", refund.PayerID).Scan(&payerName, &payerEmail)
+	db.QueryRowContext(ctx, "SELECT username, email FROM users WHERE id = ?", refund.CreditorID).Scan(&creditorName, &creditorEmail)
+	db.QueryRowContext(ctx, "SELECT name FROM groups WHERE id = ?", refund.GroupID).Scan(&groupName)
+
+	amount := refund.Amount.StringFixed(2)
+	go func() {
+		if err := utils.SendSplitRefundEmail(payerEmail, payerName, creditorName, amount, groupName, refund.SplitID, true, time.Now()); err != nil {
+			utils.Logger.Errorf("failed to send refund email to %s: %v", payerEmail, err)
+		}
+		if err := utils.SendSplitRefundEmail(creditorEmail, creditorName, payerName, amount, groupName, refund.SplitID, false, time.Now()); err != nil {
+			utils.Logger.Errorf("failed to send refund email to %s: %v", creditorEmail, err)
+		}
+	}()
+}
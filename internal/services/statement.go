@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"qiyana_paybuddy/internal/models"
+	"qiyana_paybuddy/pkg/pdf"
+	"qiyana_paybuddy/pkg/utils"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StatementEntry is a transaction with the wallet balance after it was applied
+type StatementEntry struct {
+	models.Transaction
+	Balance decimal.Decimal
+}
+
+// Statement is a user's wallet activity between two dates, inclusive
+type Statement struct {
+	UserID         int
+	AccountName    string
+	Email          string
+	From           time.Time
+	To             time.Time
+	OpeningBalance decimal.Decimal
+	ClosingBalance decimal.Decimal
+	TotalIn        decimal.Decimal
+	TotalOut       decimal.Decimal
+	Entries        []StatementEntry
+	GeneratedAt    time.Time
+}
+
+func signedAmount(transactionType string, amount decimal.Decimal) decimal.Decimal {
+	if transactionType == "debit" {
+		return amount.Neg()
+	}
+	return amount
+}
+
+// BuildStatement reads the wallet and transactions for a statement period. The
+// opening balance is derived by unwinding successful transactions from the
+// current wallet balance, since balances are not snapshotted.
+func BuildStatement(ctx context.Context, db *sql.DB, userID int, from, to time.Time) (*Statement, error) {
+	st := &Statement{UserID: userID, From: from, To: to, GeneratedAt: time.Now()}
+
+	var firstName, lastName string
+	err := db.QueryRowContext(ctx, "SELECT first_name, last_name, email FROM users WHERE id = ?", userID).
+		Scan(&firstName, &lastName, &st.Email)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to fetch account holder")
+	}
+	st.AccountName = firstName + " " + lastName
+
+	var current decimal.Decimal
+	err = db.QueryRowContext(ctx, "SELECT balance FROM wallets WHERE user_id = ?", userID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrWalletNotFound
+		}
+		return nil, utils.ErrorHandler(err, "failed to fetch wallet")
+	}
+
+	fromStr := from.Format("2006-01-02 15:04:05")
+	endStr := to.AddDate(0, 0, 1).Format("2006-01-02 15:04:05")
+
+	var movedSince decimal.Decimal
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE -amount END), 0)
+		FROM transactions WHERE user_id = ? AND status = 'success' AND created_at >= ?
+	`, userID, fromStr).Scan(&movedSince)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to compute opening balance")
+	}
+	st.OpeningBalance = current.Sub(movedSince)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, transaction_type, category, amount, status, reference, description, reversal_of, created_at, updated_at
+		FROM transactions
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+	`, userID, fromStr, endStr)
+	if err != nil {
+		return nil, utils.ErrorHandler(err, "failed to fetch statement transactions")
+	}
+	defer rows.Close()
+
+	balance := st.OpeningBalance
+	st.TotalIn, st.TotalOut = decimal.Zero, decimal.Zero
+	for rows.Next() {
+		var t models.Transaction
+		var description sql.NullString
+		if err := rows.Scan(&t.ID, &t.TransactionType, &t.Category, &t.Amount, &t.Status, &t.Reference, &description, &t.ReversalOf, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, utils.ErrorHandler(err, "failed to read statement transaction")
+		}
+		t.Description = description.String
+
+		// only completed transactions move the balance
+		if t.Status == "success" {
+			balance = balance.Add(signedAmount(t.TransactionType, t.Amount))
+			if t.TransactionType == "credit" {
+				st.TotalIn = st.TotalIn.Add(t.Amount)
+			} else {
+				st.TotalOut = st.TotalOut.Add(t.Amount)
+			}
+		}
+		st.Entries = append(st.Entries, StatementEntry{Transaction: t, Balance: balance})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, utils.ErrorHandler(err, "failed to read statement transactions")
+	}
+	st.ClosingBalance = balance
+
+	return st, nil
+}
+
+// Filename is the suggested download name for the statement
+func (st *Statement) Filename(ext string) string {
+	return fmt.Sprintf("qiyana-statement-%s-to-%s.%s", st.From.Format("20060102"), st.To.Format("20060102"), ext)
+}
+
+// CSV renders the statement with a summary header followed by one row per transaction
+func (st *Statement) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	records := [][]string{
+		{"Qiyana Pay Buddy Account Statement"},
+		{"Account holder", st.AccountName},
+		{"Email", st.Email},
+		{"Period", st.From.Format("2006-01-02"), st.To.Format("2006-01-02")},
+		{"Opening balance", st.OpeningBalance.StringFixed(2)},
+		{"Total in", st.TotalIn.StringFixed(2)},
+		{"Total out", st.TotalOut.StringFixed(2)},
+		{"Closing balance", st.ClosingBalance.StringFixed(2)},
+		{},
+		{"Date", "Reference", "Description", "Category", "Type", "Status", "Amount", "Balance"},
+	}
+	for _, e := range st.Entries {
+		records = append(records, []string{
+			e.CreatedAt.String,
+			e.Reference,
+			e.Description,
+			e.Category,
+			e.TransactionType,
+			e.Status,
+			signedAmount(e.TransactionType, e.Amount).StringFixed(2),
+			e.Balance.StringFixed(2),
+		})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PDF renders a branded, paginated statement
+func (st *Statement) PDF() []byte {
+	brand := pdf.Hex(0x0a4d3c)
+	tint := pdf.Hex(0xf2fdf6)
+	rule := pdf.Hex(0xdddddd)
+
+	const left, right = 40.0, pdf.PageWidth - 40
+	columns := []struct {
+		title string
+		x     float64
+		right bool
+	}{
+		{"Date", left, false},
+		{"Reference", left + 70, false},
+		{"Description", left + 170, false},
+		{"Status", left + 345, false},
+		{"Amount", left + 455, true},
+		{"Balance", right, true},
+	}
+
+	doc := pdf.New()
+	var y float64
+
+	tableHeader := func() {
+		doc.FillRect(left, y, right-left, 18, brand)
+		for _, c := range columns {
+			if c.right {
+				doc.TextRight(c.x-4, y+12, 8, true, pdf.White, c.title)
+			} else {
+				doc.Text(c.x+4, y+12, 8, true, pdf.White, c.title)
+			}
+		}
+		y += 24
+	}
+
+	footer := func() {
+		doc.Line(left, pdf.PageHeight-40, right, pdf.PageHeight-40, 0.5, rule)
+		doc.Text(left, pdf.PageHeight-28, 7, false, pdf.Grey,
+			fmt.Sprintf("(c) %d Qiyana Pay Buddy - Smarter Sharing. Stronger Bonds.", st.GeneratedAt.Year()))
+		doc.TextRight(right, pdf.PageHeight-28, 7, false, pdf.Grey, fmt.Sprintf("Page %d", doc.PageCount()))
+	}
+
+	doc.AddPage()
+	doc.FillRect(0, 0, pdf.PageWidth, 70, brand)
+	doc.Text(left, 32, 18, true, pdf.White, "Qiyana Pay Buddy")
+	doc.Text(left, 52, 10, false, pdf.White, "Account Statement")
+	doc.TextRight(right, 32, 9, false, pdf.White, fmt.Sprintf("%s to %s", st.From.Format("Jan 2, 2006"), st.To.Format("Jan 2, 2006")))
+	doc.TextRight(right, 52, 8, false, pdf.White, "Generated "+st.GeneratedAt.Format("Jan 2, 2006 3:04 PM"))
+
+	doc.Text(left, 98, 11, true, pdf.Black, st.AccountName)
+	doc.Text(left, 112, 9, false, pdf.Grey, st.Email)
+
+	doc.FillRect(left, 128, right-left, 50, tint)
+	summary := []struct {
+		label string
+		value decimal.Decimal
+	}{
+		{"Opening balance", st.OpeningBalance},
+		{"Money in", st.TotalIn},
+		{"Money out", st.TotalOut},
+		{"Closing balance", st.ClosingBalance},
+	}
+	colWidth := (right - left) / float64(len(summary))
+	for i, s := range summary {
+		x := left + 10 + float64(i)*colWidth
+		doc.Text(x, 146, 8, false, pdf.Grey, s.label)
+		doc.Text(x, 164, 11, true, brand, "NGN "+s.value.StringFixed(2))
+	}
+
+	y = 198
+	tableHeader()
+
+	if len(st.Entries) == 0 {
+		doc.Text(left+4, y+4, 9, false, pdf.Grey, "No transactions in this period.")
+	}
+
+	for _, e := range st.Entries {
+		if y > pdf.PageHeight-70 {
+			footer()
+			doc.AddPage()
+			y = 40
+			tableHeader()
+		}
+
+		description := e.Description
+		if runes := []rune(description); len(runes) > 32 {
+			description = string(runes[:31]) + "..."
+		}
+		date := e.CreatedAt.String
+		if len(date) >= 10 {
+			date = date[:10]
+		}
+
+		amountColor := brand
+		if e.TransactionType == "debit" {
+			amountColor = pdf.Hex(0xb03a2e)
+		}
+
+		doc.Text(columns[0].x+4, y+4, 8, false, pdf.Black, date)
+		doc.Text(columns[1].x+4, y+4, 8, false, pdf.Black, e.Reference)
+		doc.Text(columns[2].x+4, y+4, 8, false, pdf.Black, description)
+		doc.Text(columns[3].x+4, y+4, 8, false, pdf.Grey, e.Status)
+		doc.TextRight(columns[4].x-4, y+4, 8, true, amountColor, signedAmount(e.TransactionType, e.Amount).StringFixed(2))
+		doc.TextRight(columns[5].x-4, y+4, 8, false, pdf.Black, e.Balance.StringFixed(2))
+		doc.Line(left, y+10, right, y+10, 0.3, rule)
+		y += 18
+	}
+
+	footer()
+	return doc.Bytes()
+}
@@ -3,12 +3,16 @@ package cron
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"qiyana_paybuddy/internal/services"
 	"qiyana_paybuddy/pkg/utils"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/robfig/cron/v3"
+	"github.com/shopspring/decimal"
 )
 
 func StartCronJob(db *sql.DB) *cron.Cron {
@@ -25,19 +29,47 @@ func StartCronJob(db *sql.DB) *cron.Cron {
 		utils.Logger.Errorf("Failed to schedule invitation expiration job: %v", err)
 	}
 
-	// Runs daily at midnight — send reminders
+	// Runs daily at midnight — send reminders, each group at its own frequency
 	_, err = c.AddFunc("0 0 * * *", func() {
 		err := SendReminderEmailsToDebtors(db)
 		if err != nil {
 			utils.Logger.Errorf("Cron job failed to send reminder emails: %v", err)
 		}
+
+		err = SendMoneyRequestReminders(db)
+		if err != nil {
+			utils.Logger.Errorf("Cron job failed to send money request reminders: %v", err)
+		}
 	})
 	if err != nil {
 		utils.Logger.Errorf("Failed to schedule debtor reminder job: %v", err)
 	}
 
+	// Runs daily at 23:30 — auto-settle debts for users who opted in
+	_, err = c.AddFunc("30 23 * * *", func() {
+		err := AutoSettleDebts(db)
+		if err != nil {
+			utils.Logger.Errorf("Cron job failed to auto-settle debts: %v", err)
+		}
+	})
+	if err != nil {
+		utils.Logger.Errorf("Failed to schedule auto-settle job: %v", err)
+	}
+
+	// Runs at 06:00 on the 1st of each month — monthly digest. The 2nd and 3rd
+	// pick up anyone missed or failed; digest_runs stops double sends.
+	_, err = c.AddFunc("0 6 1-3 * *", func() {
+		err := SendMonthlyDigests(db)
+		if err != nil {
+			utils.Logger.Errorf("Cron job failed to send monthly digests: %v", err)
+		}
+	})
+	if err != nil {
+		utils.Logger.Errorf("Failed to schedule monthly digest job: %v", err)
+	}
+
 	c.Start()
-	utils.Logger.Info("Cron jobs started (invitation expiry every 6h, debtor reminders daily at midnight)")
+	utils.Logger.Info("Cron jobs started (invitation expiry every 6h, debtor reminders daily at midnight, auto-settle daily at 23:30, monthly digest on the 1st at 06:00)")
 	return c
 }
 
@@ -79,19 +111,37 @@ func CheckAndUpdateExpiredInvitations(db *sql.DB) error {
 	return nil
 }
 
+// reminderFrequenciesDue lists the group reminder frequencies that are due on
+// day. Weekly reminders go out on Mondays and monthly ones on the 1st.
+func reminderFrequenciesDue(day time.Time) []interface{} {
+	due := []interface{}{"daily"}
+	if day.Weekday() == time.Monday {
+		due = append(due, "weekly")
+	}
+	if day.Day() == 1 {
+		due = append(due, "monthly")
+	}
+	return due
+}
+
 // -------------------------------------------------------------
-// Send daily reminders to debtors (now runs email sends concurrently)
+// Send reminders to debtors, as often as each group's settings
+// ask for (now runs email sends concurrently)
 // -------------------------------------------------------------
 func SendReminderEmailsToDebtors(db *sql.DB) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
 
+	due := reminderFrequenciesDue(time.Now())
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(due)), ", ")
+
 	rows, err := db.QueryContext(ctx, `
 		SELECT 
 			s.owed_by,
 			u.email,
 			u.first_name,
 			g.name AS group_name,
+			COALESCE(gs.currency, 'NGN') AS currency,
 			e.description AS expense_title,
 			e.created_at,
 			SUM(s.amount_owed) AS total_owed
@@ -99,9 +149,14 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 		JOIN group_expenses e ON s.expense_id = e.id
 		JOIN groups g ON e.group_id = g.id
 		JOIN users u ON s.owed_by = u.id
+		LEFT JOIN group_settings gs ON gs.group_id = g.id
 		WHERE s.is_settled = FALSE
+			AND COALESCE(gs.reminder_frequency, 'daily') IN (`+placeholders+`)
+			AND NOT EXISTS (
+				SELECT 1 FROM disputes d WHERE d.split_id = s.id AND d.status IN ('open', 'responded')
+			)
 		GROUP BY s.owed_by, e.id
-	`)
+	`, due...)
 	if err != nil {
 		return err
 	}
@@ -112,9 +167,9 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 
 	for rows.Next() {
 		var (
-			email, firstName, groupName, expenseTitle string
-			expenseCreatedAtRaw                       sql.NullString
-			totalOwed                                 float64
+			email, firstName, groupName, currency, expenseTitle string
+			expenseCreatedAtRaw                                 sql.NullString
+			totalOwed                                           float64
 		)
 
 		if err := rows.Scan(
@@ -122,6 +177,7 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 			&email,
 			&firstName,
 			&groupName,
+			&currency,
 			&expenseTitle,
 			&expenseCreatedAtRaw,
 			&totalOwed,
@@ -142,7 +198,7 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 		}
 
 		wg.Add(1)
-		go func(email, firstName, groupName, expenseTitle string, totalOwed float64, expenseCreatedAt time.Time) {
+		go func(email, firstName, groupName, currency, expenseTitle string, totalOwed float64, expenseCreatedAt time.Time) {
 			defer wg.Done()
 
 			totalOwedStr := fmt.Sprintf("%.2f", totalOwed)
@@ -150,6 +206,7 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 			if err := utils.SendDebtorReminderEmail(
 				email,
 				firstName,
+				currency,
 				totalOwedStr,
 				groupName,
 				expenseTitle,
@@ -159,9 +216,9 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 				return
 			}
 
-			utils.Logger.Infof("📧 Sent reminder to %s (%s) — ₦%.2f for '%s' in '%s'",
-				firstName, email, totalOwed, expenseTitle, groupName)
-		}(email, firstName, groupName, expenseTitle, totalOwed, expenseCreatedAt)
+			utils.Logger.Infof("📧 Sent reminder to %s (%s) — %s%.2f for '%s' in '%s'",
+				firstName, email, utils.CurrencySymbol(currency), totalOwed, expenseTitle, groupName)
+		}(email, firstName, groupName, currency, expenseTitle, totalOwed, expenseCreatedAt)
 	}
 
 	wg.Wait()
@@ -179,3 +236,399 @@ func SendReminderEmailsToDebtors(db *sql.DB) error {
 	utils.Logger.Info("✅ Finished sending all debtor reminder emails.")
 	return nil
 }
+
+// -------------------------------------------------------------
+// Remind recipients of money requests that are still pending
+// -------------------------------------------------------------
+func SendMoneyRequestReminders(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.email, u.first_name, rq.username, r.amount, r.reason, r.due_date
+		FROM money_requests r
+		JOIN users u ON u.id = r.recipient_id
+		JOIN users rq ON rq.id = r.requester_id
+		WHERE r.status = 'pending' AND r.created_at < ?
+	`, time.Now().Add(-24*time.Hour).Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var wg sync.WaitGroup
+	for rows.Next() {
+		var (
+			email, firstName, requesterName, reason string
+			amount                                  decimal.Decimal
+			dueDate                                 sql.NullString
+		)
+
+		if err := rows.Scan(&email, &firstName, &requesterName, &amount, &reason, &dueDate); err != nil {
+			utils.Logger.Errorf("Failed to scan money request row: %v", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(email, firstName, requesterName, amount, reason, dueDate string) {
+			defer wg.Done()
+			if err := utils.SendMoneyRequestEmail(email, firstName, requesterName, amount, reason, dueDate, true); err != nil {
+				utils.Logger.Errorf("failed to send money request reminder to %s: %v", email, err)
+			}
+		}(email, firstName, requesterName, amount.StringFixed(2), reason, dueDate.String)
+	}
+
+	wg.Wait()
+
+	if err := rows.Err(); err != nil {
+		utils.Logger.Errorf("Error iterating money request rows: %v", err)
+		return err
+	}
+
+	utils.Logger.Info("✅ Finished sending money request reminders.")
+	return nil
+}
+
+// -------------------------------------------------------------
+// Auto-settle unsettled splits for users who opted in, oldest first,
+// without letting the wallet drop below the user's floor balance
+// -------------------------------------------------------------
+func AutoSettleDebts(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT p.user_id, p.auto_settle_floor, u.email, u.first_name
+		FROM user_preferences p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.auto_settle_enabled = TRUE AND u.inactive_status = FALSE
+	`)
+	if err != nil {
+		return err
+	}
+
+	type autoSettleUser struct {
+		userID    int
+		floor     decimal.Decimal
+		email     string
+		firstName string
+	}
+
+	var users []autoSettleUser
+	for rows.Next() {
+		var u autoSettleUser
+		if err := rows.Scan(&u.userID, &u.floor, &u.email, &u.firstName); err != nil {
+			utils.Logger.Errorf("Failed to scan auto-settle user: %v", err)
+			continue
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		items, total, balance, err := autoSettleUserDebts(ctx, db, u.userID, u.floor)
+		if err != nil {
+			utils.Logger.Errorf("Auto-settle failed for user %d: %v", u.userID, err)
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		go func(email, firstName string, items []utils.AutoSettleItem, total, balance decimal.Decimal) {
+			if err := utils.SendAutoSettleSummaryEmail(email, firstName, items, total.StringFixed(2), balance.StringFixed(2)); err != nil {
+				utils.Logger.Errorf("failed to send auto-settle summary to %s: %v", email, err)
+			}
+		}(u.email, u.firstName, items, total, balance)
+
+		utils.Logger.Infof("Auto-settled %d splits for user %d (₦%s)", len(items), u.userID, total.StringFixed(2))
+	}
+
+	utils.Logger.Info("✅ Finished auto-settling debts.")
+	return nil
+}
+
+func autoSettleUserDebts(ctx context.Context, db *sql.DB, userID int, floor decimal.Decimal) ([]utils.AutoSettleItem, decimal.Decimal, decimal.Decimal, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT s.id, s.amount_owed, g.name, e.description
+		FROM group_expense_splits s
+		JOIN group_expenses e ON s.expense_id = e.id
+		JOIN groups g ON e.group_id = g.id
+		LEFT JOIN group_settings gs ON gs.group_id = g.id
+		WHERE s.owed_by = ? AND s.is_settled = FALSE AND s.amount_owed > 0
+			AND COALESCE(gs.currency, 'NGN') = 'NGN'
+			AND NOT EXISTS (
+				SELECT 1 FROM disputes d WHERE d.split_id = s.id AND d.status IN ('open', 'responded')
+			)
+		ORDER BY e.created_at ASC, s.id ASC
+	`, userID)
+	if err != nil {
+		return nil, decimal.Zero, decimal.Zero, err
+	}
+
+	type openSplit struct {
+		id           int
+		amount       decimal.Decimal
+		groupName    string
+		expenseTitle string
+	}
+
+	var splits []openSplit
+	for rows.Next() {
+		var s openSplit
+		if err := rows.Scan(&s.id, &s.amount, &s.groupName, &s.expenseTitle); err != nil {
+			utils.Logger.Errorf("Failed to scan split for auto-settle: %v", err)
+			continue
+		}
+		splits = append(splits, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, decimal.Zero, decimal.Zero, err
+	}
+
+	var items []utils.AutoSettleItem
+	total := decimal.Zero
+	balance := decimal.Zero
+
+	for _, s := range splits {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return items, total, balance, err
+		}
+
+		// the split may have been paid down since it was listed
+		err = tx.QueryRowContext(ctx, "SELECT amount_owed FROM group_expense_splits WHERE id = ? AND is_settled = FALSE FOR UPDATE", s.id).Scan(&s.amount)
+		if err != nil || s.amount.LessThanOrEqual(decimal.Zero) {
+			tx.Rollback()
+			if err != nil && err != sql.ErrNoRows {
+				return items, total, balance, err
+			}
+			continue
+		}
+
+		var current decimal.Decimal
+		err = tx.QueryRowContext(ctx, "SELECT balance FROM wallets WHERE user_id = ? FOR UPDATE", userID).Scan(&current)
+		if err != nil {
+			tx.Rollback()
+			return items, total, balance, err
+		}
+
+		// oldest debts are paid first, so stop as soon as one would breach the floor
+		if current.Sub(s.amount).LessThan(floor) {
+			tx.Rollback()
+			break
+		}
+
+		settlement, err := services.SettleSplit(ctx, tx, s.id, userID, s.amount, false)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, services.ErrInsufficientFunds) {
+				break
+			}
+			utils.Logger.Errorf("Failed to auto-settle split %d: %v", s.id, err)
+			continue
+		}
+
+		if err := tx.Commit(); err != nil {
+			return items, total, balance, err
+		}
+
+		balance = settlement.Transfer.PayerBalance
+		total = total.Add(s.amount)
+		items = append(items, utils.AutoSettleItem{
+			SplitID:      s.id,
+			GroupName:    s.groupName,
+			ExpenseTitle: s.expenseTitle,
+			Amount:       s.amount.StringFixed(2),
+		})
+
+		services.NotifySplitPayment(ctx, db, userID, settlement)
+	}
+
+	return items, total, balance, nil
+}
+
+// -------------------------------------------------------------
+// Monthly digest of the previous month's activity. Each user is
+// claimed in digest_runs before sending, so a rerun after a crash
+// only picks up users who were never claimed, whose send failed or
+// whose claim has been stuck in sending for over an hour
+// -------------------------------------------------------------
+func SendMonthlyDigests(db *sql.DB) error {
+	now := time.Now()
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, -1, 0)
+	period := start.Format("2006-01")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	rows, err := db.QueryContext(ctx, `
+		SELECT u.id, u.email, u.first_name
+		FROM users u
+		LEFT JOIN user_preferences p ON p.user_id = u.id
+		LEFT JOIN digest_runs d ON d.user_id = u.id AND d.period = ?
+		WHERE u.inactive_status = FALSE AND u.email_confirmed = TRUE
+			AND COALESCE(p.monthly_digest_enabled, TRUE) = TRUE
+			AND (d.id IS NULL OR d.status = 'failed' OR (d.status = 'sending' AND d.updated_at < NOW() - INTERVAL 1 HOUR))
+	`, period)
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	type recipient struct {
+		id        int
+		email     string
+		firstName string
+	}
+
+	var recipients []recipient
+	for rows.Next() {
+		var rc recipient
+		if err := rows.Scan(&rc.id, &rc.email, &rc.firstName); err != nil {
+			utils.Logger.Errorf("Failed to scan digest recipient: %v", err)
+			continue
+		}
+		recipients = append(recipients, rc)
+	}
+	rows.Close()
+	cancel()
+
+	sent := 0
+	for _, rc := range recipients {
+		if err := sendMonthlyDigest(db, rc.id, rc.email, rc.firstName, period, start, end); err != nil {
+			utils.Logger.Errorf("Failed to send %s digest to %s: %v", period, rc.email, err)
+			continue
+		}
+		sent++
+	}
+
+	utils.Logger.Infof("✅ Sent %d monthly digests for %s.", sent, period)
+	return nil
+}
+
+func sendMonthlyDigest(db *sql.DB, userID int, email, firstName, period string, start, end time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// claim the user for this period; a run that is sent, or has been sending for less
+	// than an hour, is left alone. A longer one died mid-send and its lease is renewed.
+	res, err := db.ExecContext(ctx, `
+		INSERT INTO digest_runs (user_id, period) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE
+			status = IF(status = 'failed' OR (status = 'sending' AND updated_at < NOW() - INTERVAL 1 HOUR), 'sending', status),
+			updated_at = IF(status = 'sending' AND updated_at < NOW() - INTERVAL 1 HOUR, NOW(), updated_at)
+	`, userID, period)
+	if err != nil {
+		return err
+	}
+	if claimed, _ := res.RowsAffected(); claimed == 0 {
+		return nil
+	}
+
+	digest, err := buildMonthlyDigest(ctx, db, userID, start, end)
+	if err == nil {
+		digest.Period = start.Format("January 2006")
+		err = utils.SendMonthlyDigestEmail(email, firstName, digest)
+	}
+
+	status, sentAt := "sent", sql.NullString{String: time.Now().Format("2006-01-02 15:04:05"), Valid: true}
+	if err != nil {
+		status, sentAt = "failed", sql.NullString{}
+	}
+	if _, updateErr := db.ExecContext(ctx, "UPDATE digest_runs SET status = ?, sent_at = ? WHERE user_id = ? AND period = ?",
+		status, sentAt, userID, period); updateErr != nil {
+		utils.Logger.Errorf("Failed to record digest status for user %d: %v", userID, updateErr)
+	}
+
+	return err
+}
+
+func buildMonthlyDigest(ctx context.Context, db *sql.DB, userID int, start, end time.Time) (utils.MonthlyDigest, error) {
+	var digest utils.MonthlyDigest
+	from, to := start.Format("2006-01-02 15:04:05"), end.Format("2006-01-02 15:04:05")
+
+	var inflow, outflow decimal.Decimal
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN transaction_type = 'debit' THEN amount ELSE 0 END), 0)
+		FROM transactions
+		WHERE user_id = ? AND status = 'success' AND created_at >= ? AND created_at < ?
+	`, userID, from, to).Scan(&inflow, &outflow)
+	if err != nil {
+		return digest, err
+	}
+	digest.Inflow, digest.Outflow = inflow.StringFixed(2), outflow.StringFixed(2)
+
+	var owedToYou, youOwe decimal.Decimal
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(CASE WHEN e.paid_by = ? THEN s.amount_owed ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN s.owed_by = ? THEN s.amount_owed ELSE 0 END), 0)
+		FROM group_expense_splits s
+		JOIN group_expenses e ON e.id = s.expense_id
+		WHERE s.is_settled = FALSE AND (e.paid_by = ? OR s.owed_by = ?)
+	`, userID, userID, userID, userID).Scan(&owedToYou, &youOwe)
+	if err != nil {
+		return digest, err
+	}
+	digest.OwedToYou, digest.YouOwe = owedToYou.StringFixed(2), youOwe.StringFixed(2)
+
+	// the payer's share is whatever the splits don't cover
+	rows, err := db.QueryContext(ctx, `
+		SELECT g.name, COUNT(*), SUM(
+			CASE WHEN e.paid_by = ?
+				THEN e.amount - (SELECT COALESCE(SUM(s.share_amount), 0) FROM group_expense_splits s WHERE s.expense_id = e.id)
+				ELSE 0 END
+			+ (SELECT COALESCE(SUM(s.share_amount), 0) FROM group_expense_splits s WHERE s.expense_id = e.id AND s.owed_by = ?)
+		) AS share
+		FROM group_expenses e
+		JOIN groups g ON g.id = e.group_id
+		WHERE e.created_at >= ? AND e.created_at < ?
+			AND (e.paid_by = ? OR EXISTS (SELECT 1 FROM group_expense_splits s WHERE s.expense_id = e.id AND s.owed_by = ?))
+		GROUP BY g.id, g.name
+		ORDER BY share DESC
+	`, userID, userID, from, to, userID, userID)
+	if err != nil {
+		return digest, err
+	}
+	for rows.Next() {
+		var g utils.DigestGroup
+		var share decimal.Decimal
+		if err := rows.Scan(&g.Name, &g.Expenses, &share); err != nil {
+			rows.Close()
+			return digest, err
+		}
+		g.Share = share.StringFixed(2)
+		digest.Groups = append(digest.Groups, g)
+	}
+	rows.Close()
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT category, SUM(amount)
+		FROM transactions
+		WHERE user_id = ? AND transaction_type = 'debit' AND status = 'success' AND created_at >= ? AND created_at < ?
+		GROUP BY category
+		ORDER BY SUM(amount) DESC
+		LIMIT 3
+	`, userID, from, to)
+	if err != nil {
+		return digest, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c utils.DigestCategory
+		var amount decimal.Decimal
+		if err := rows.Scan(&c.Name, &amount); err != nil {
+			return digest, err
+		}
+		c.Amount = amount.StringFixed(2)
+		digest.TopCategories = append(digest.TopCategories, c)
+	}
+
+	return digest, rows.Err()
+}
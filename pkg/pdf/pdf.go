@@ -0,0 +1,166 @@
+// Package pdf is a small PDF 1.4 writer for server-generated documents such as
+// account statements. It supports A4 pages with text in the built-in Helvetica
+// fonts, lines and filled rectangles, which is all our documents need.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color is an RGB colour with components from 0 to 1
+type Color struct {
+	R, G, B float64
+}
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{1, 1, 1}
+	Grey  = Color{0.45, 0.45, 0.45}
+)
+
+// Hex builds a Color from a value such as 0x0a4d3c
+func Hex(v uint32) Color {
+	return Color{
+		R: float64((v>>16)&0xff) / 255,
+		G: float64((v>>8)&0xff) / 255,
+		B: float64(v&0xff) / 255,
+	}
+}
+
+// Document accumulates page content streams until Bytes is called.
+// Coordinates are in points measured from the top-left corner of the page.
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new A4 page; drawing calls go to the latest page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages added so far
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline at (x, y)
+func (d *Document) Text(x, y, size float64, bold bool, c Color, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.2f Tf %.3f %.3f %.3f rg %.2f %.2f Td (%s) Tj ET\n",
+		font, size, c.R, c.G, c.B, x, PageHeight-y, escape(s))
+}
+
+// TextRight draws s so that it ends at x, using Helvetica's average glyph width
+func (d *Document) TextRight(x, y, size float64, bold bool, c Color, s string) {
+	d.Text(x-TextWidth(s, size, bold), y, size, bold, c, s)
+}
+
+// Line draws a straight line between two points
+func (d *Document) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		c.R, c.G, c.B, width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// FillRect fills a rectangle whose top-left corner is at (x, y)
+func (d *Document) FillRect(x, y, w, h float64, c Color) {
+	fmt.Fprintf(d.page(), "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+		c.R, c.G, c.B, x, PageHeight-y-h, w, h)
+}
+
+// TextWidth estimates the rendered width of s. Digits and most lowercase
+// letters in Helvetica are close to 0.55em, which is accurate enough to
+// right-align numeric columns.
+func TextWidth(s string, size float64, bold bool) float64 {
+	factor := 0.52
+	if bold {
+		factor = 0.56
+	}
+	return float64(len(toLatin(s))) * size * factor
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+
+	writeObj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// objects 1-4 are fixed, each page then takes a page object and a content stream
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+
+	writeObj("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range d.pages {
+		writeObj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, 6+i*2))
+		writeObj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// toLatin maps s onto the single-byte WinAnsi range used by the built-in fonts
+func toLatin(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '₦':
+			b.WriteString("NGN ")
+		case r == '—' || r == '–':
+			b.WriteByte('-')
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", " ").Replace(toLatin(s))
+}
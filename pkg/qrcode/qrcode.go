@@ -0,0 +1,465 @@
+// Package qrcode is a small QR code encoder for short strings such as join
+// links. It encodes in byte mode at error correction level M and supports
+// versions 1 to 10, which holds up to 213 bytes, and renders to PNG or SVG.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QuietZone is the light border, in modules, drawn around the code
+const QuietZone = 4
+
+var ErrTooLong = errors.New("qrcode: data too long to encode")
+
+// versionInfo describes the error correction layout of a version at level M
+type versionInfo struct {
+	ecPerBlock int
+	groups     [2]struct{ blocks, dataPerBlock int }
+	alignment  []int
+}
+
+var versions = []versionInfo{
+	{},
+	{10, [2]struct{ blocks, dataPerBlock int }{{1, 16}, {0, 0}}, nil},
+	{16, [2]struct{ blocks, dataPerBlock int }{{1, 28}, {0, 0}}, []int{6, 18}},
+	{26, [2]struct{ blocks, dataPerBlock int }{{1, 44}, {0, 0}}, []int{6, 22}},
+	{18, [2]struct{ blocks, dataPerBlock int }{{2, 32}, {0, 0}}, []int{6, 26}},
+	{24, [2]struct{ blocks, dataPerBlock int }{{2, 43}, {0, 0}}, []int{6, 30}},
+	{16, [2]struct{ blocks, dataPerBlock int }{{4, 27}, {0, 0}}, []int{6, 34}},
+	{18, [2]struct{ blocks, dataPerBlock int }{{4, 31}, {0, 0}}, []int{6, 22, 38}},
+	{22, [2]struct{ blocks, dataPerBlock int }{{2, 38}, {2, 39}}, []int{6, 24, 42}},
+	{22, [2]struct{ blocks, dataPerBlock int }{{3, 36}, {2, 37}}, []int{6, 26, 46}},
+	{26, [2]struct{ blocks, dataPerBlock int }{{4, 43}, {1, 44}}, []int{6, 28, 50}},
+}
+
+func (v versionInfo) dataCodewords() int {
+	return v.groups[0].blocks*v.groups[0].dataPerBlock + v.groups[1].blocks*v.groups[1].dataPerBlock
+}
+
+// Code is an encoded QR symbol
+type Code struct {
+	Version  int
+	Size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y][x]
+}
+
+// Encode builds the smallest QR code that holds data
+func Encode(data string) (*Code, error) {
+	version := 0
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 <= versions[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	c := &Code{Version: version, Size: version*4 + 17}
+	c.modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, c.Size)
+		c.function[i] = make([]bool, c.Size)
+	}
+
+	c.drawFunctionPatterns()
+	c.placeData(interleave(version, encodeData(version, []byte(data))))
+
+	// keep the mask with the lowest penalty; masking is its own inverse
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormatBits(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask)
+	}
+	c.applyMask(best)
+	c.drawFormatBits(best)
+
+	return c, nil
+}
+
+// encodeData builds the data codewords: mode, length, payload, terminator and padding
+func encodeData(version int, data []byte) []byte {
+	var bits []bool
+	appendBits := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	appendBits(0x4, 4)
+	appendBits(len(data), countBits)
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	capacity := versions[version].dataCodewords() * 8
+	for i := 0; i < 4 && len(bits) < capacity; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	codewords := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// interleave splits the data into blocks, adds error correction to each and
+// interleaves the result in the order the symbol expects
+func interleave(version int, data []byte) []byte {
+	info := versions[version]
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for _, g := range info.groups {
+		for i := 0; i < g.blocks; i++ {
+			block := data[offset : offset+g.dataPerBlock]
+			offset += g.dataPerBlock
+			dataBlocks = append(dataBlocks, block)
+			ecBlocks = append(ecBlocks, reedSolomon(block, info.ecPerBlock))
+		}
+	}
+
+	var out []byte
+	longest := info.groups[0].dataPerBlock
+	if info.groups[1].dataPerBlock > longest {
+		longest = info.groups[1].dataPerBlock
+	}
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	positions := versions[c.Version].alignment
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// the three corners already hold finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// reserve the format areas; the real bits are drawn once the mask is chosen
+	c.drawFormatBits(0)
+	c.drawVersionBits()
+}
+
+// drawFinder draws a finder pattern and its separator centred on x, y
+func (c *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			c.set(xx, yy, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (c *Code) drawFormatBits(mask int) {
+	// level M is 00, so the data is just the mask
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+func (c *Code) drawVersionBits() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := (bits>>i)&1 == 1
+		a, b := c.Size-11+i%3, i/3
+		c.set(a, b, dark)
+		c.set(b, a, dark)
+	}
+}
+
+// placeData fills the non-function modules in the two-column zigzag order
+func (c *Code) placeData(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.function[y][x] {
+					continue
+				}
+				if i < len(codewords)*8 {
+					c.modules[y][x] = (codewords[i>>3]>>(7-(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the specification
+func (c *Code) penalty() int {
+	score := 0
+	finderLike := []bool{true, false, true, true, true, false, true}
+
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i < c.Size; i++ {
+			if get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+			run = 1
+		}
+		if run >= 5 {
+			score += run - 2
+		}
+
+		// a finder-like 1:1:3:1:1 pattern with four light modules on either side
+		for i := 0; i+7 <= c.Size; i++ {
+			match := true
+			for k, dark := range finderLike {
+				if get(i+k) != dark {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+			before, after := true, true
+			for k := 1; k <= 4; k++ {
+				if i-k >= 0 && get(i-k) {
+					before = false
+				}
+				if i+6+k < c.Size && get(i+6+k) {
+					after = false
+				}
+			}
+			if before || after {
+				score += 40
+			}
+		}
+	}
+
+	for y := 0; y < c.Size; y++ {
+		line(func(i int) bool { return c.modules[y][i] })
+	}
+	for x := 0; x < c.Size; x++ {
+		line(func(i int) bool { return c.modules[i][x] })
+	}
+
+	dark := 0
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < c.Size && y+1 < c.Size {
+				d := c.modules[y][x]
+				if c.modules[y][x+1] == d && c.modules[y+1][x] == d && c.modules[y+1][x+1] == d {
+					score += 3
+				}
+			}
+		}
+	}
+
+	percent := dark * 100 / (c.Size * c.Size)
+	score += abs(percent-50) / 5 * 10
+	return score
+}
+
+// PNG renders the code with scale pixels per module
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	side := (c.Size + 2*QuietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((x+QuietZone)*scale+dx, (y+QuietZone)*scale+dy, color.Gray{Y: 0})
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as a scalable image, one unit per module
+func (c *Code) SVG() string {
+	side := c.Size + 2*QuietZone
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="100%%" height="100%%" fill="#ffffff"/><path d="%s" fill="#000000"/></svg>`, side, side, path.String())
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,236 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		degree int
+		want   []byte
+	}{
+		{
+			// HELLO WORLD at 1-M, the worked example from the specification
+			name:   "version 1-M",
+			data:   []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			degree: 10,
+			want:   []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+		{
+			name:   "all zero data",
+			data:   make([]byte, 16),
+			degree: 10,
+			want:   make([]byte, 10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reedSolomon(tt.data, tt.degree)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("reedSolomon() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	// coefficients of the degree 7 generator written as powers of alpha:
+	// x^7 + a^87 x^6 + a^229 x^5 + a^146 x^4 + a^149 x^3 + a^238 x^2 + a^102 x + a^21
+	exponents := []int{87, 229, 146, 149, 238, 102, 21}
+	got := generator(7)
+	if len(got) != len(exponents) {
+		t.Fatalf("generator(7) has %d coefficients, want %d", len(got), len(exponents))
+	}
+	for i, e := range exponents {
+		if got[i] != gfExp[e] {
+			t.Errorf("coefficient %d = %d, want a^%d = %d", i, got[i], e, gfExp[e])
+		}
+	}
+}
+
+func newCode(version int) *Code {
+	c := &Code{Version: version, Size: version*4 + 17}
+	c.modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+	for i := range c.modules {
+		c.modules[i] = make([]bool, c.Size)
+		c.function[i] = make([]bool, c.Size)
+	}
+	return c
+}
+
+func TestFormatBits(t *testing.T) {
+	// 15-bit format strings for level M, already masked with 0x5412
+	tests := []struct {
+		mask int
+		want int
+	}{
+		{0, 0x5412},
+		{1, 0x5125},
+		{2, 0x5E7C},
+		{3, 0x5B4B},
+		{4, 0x45F9},
+		{5, 0x40CE},
+		{6, 0x4F97},
+		{7, 0x4AA0},
+	}
+
+	for _, tt := range tests {
+		c := newCode(1)
+		c.drawFormatBits(tt.mask)
+
+		// read both copies back: around the top-left finder, and split between
+		// the top-right and bottom-left finders
+		var first, second int
+		for i := 0; i <= 5; i++ {
+			if c.Dark(8, i) {
+				first |= 1 << i
+			}
+		}
+		if c.Dark(8, 7) {
+			first |= 1 << 6
+		}
+		if c.Dark(8, 8) {
+			first |= 1 << 7
+		}
+		if c.Dark(7, 8) {
+			first |= 1 << 8
+		}
+		for i := 9; i < 15; i++ {
+			if c.Dark(14-i, 8) {
+				first |= 1 << i
+			}
+		}
+		for i := 0; i < 8; i++ {
+			if c.Dark(c.Size-1-i, 8) {
+				second |= 1 << i
+			}
+		}
+		for i := 8; i < 15; i++ {
+			if c.Dark(8, c.Size-15+i) {
+				second |= 1 << i
+			}
+		}
+
+		if first != tt.want {
+			t.Errorf("mask %d: first copy = %#x, want %#x", tt.mask, first, tt.want)
+		}
+		if second != tt.want {
+			t.Errorf("mask %d: second copy = %#x, want %#x", tt.mask, second, tt.want)
+		}
+		if !c.Dark(8, c.Size-8) {
+			t.Errorf("mask %d: dark module is not set", tt.mask)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// 18-bit version strings, 6 bits of version then 12 of BCH remainder
+	tests := []struct {
+		version int
+		want    int
+	}{
+		{7, 0x07C94},
+		{8, 0x085BC},
+		{9, 0x09A99},
+		{10, 0x0A4D3},
+	}
+
+	for _, tt := range tests {
+		c := newCode(tt.version)
+		c.drawVersionBits()
+
+		var upperRight, lowerLeft int
+		for i := 0; i < 18; i++ {
+			a, b := c.Size-11+i%3, i/3
+			if c.Dark(a, b) {
+				upperRight |= 1 << i
+			}
+			if c.Dark(b, a) {
+				lowerLeft |= 1 << i
+			}
+		}
+		if upperRight != tt.want {
+			t.Errorf("version %d: upper right block = %#x, want %#x", tt.version, upperRight, tt.want)
+		}
+		if lowerLeft != tt.want {
+			t.Errorf("version %d: lower left block = %#x, want %#x", tt.version, lowerLeft, tt.want)
+		}
+	}
+
+	// versions below 7 carry no version information
+	c := newCode(6)
+	c.drawVersionBits()
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.function[y][x] {
+				t.Fatalf("version 6 reserved module %d,%d for version bits", x, y)
+			}
+		}
+	}
+}
+
+func TestVersionTable(t *testing.T) {
+	// total codewords per version from the specification; data plus error
+	// correction across all blocks must add up to it
+	total := []int{0, 26, 44, 70, 100, 134, 172, 196, 242, 292, 346}
+
+	for v := 1; v < len(versions); v++ {
+		info := versions[v]
+		blocks := info.groups[0].blocks + info.groups[1].blocks
+		if got := info.dataCodewords() + blocks*info.ecPerBlock; got != total[v] {
+			t.Errorf("version %d: %d codewords, want %d", v, got, total[v])
+		}
+		if got := len(interleave(v, make([]byte, info.dataCodewords()))); got != total[v] {
+			t.Errorf("version %d: interleave returned %d codewords, want %d", v, got, total[v])
+		}
+	}
+}
+
+func TestEncodeData(t *testing.T) {
+	// byte mode "A" at 1-M: mode 0100, length 00000001, 01000001, terminator,
+	// then alternating pad codewords
+	want := []byte{0x40, 0x14, 0x10, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}
+	if got := encodeData(1, []byte("A")); !bytes.Equal(got, want) {
+		t.Errorf("encodeData() = %x, want %x", got, want)
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	tests := []struct {
+		length  int
+		version int
+		err     error
+	}{
+		{0, 1, nil},
+		{14, 1, nil},
+		{15, 2, nil},
+		{180, 9, nil},
+		{181, 10, nil},
+		{213, 10, nil},
+		{214, 0, ErrTooLong},
+	}
+
+	for _, tt := range tests {
+		c, err := Encode(strings.Repeat("a", tt.length))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("length %d: err = %v, want %v", tt.length, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if c.Version != tt.version {
+			t.Errorf("length %d: version = %d, want %d", tt.length, c.Version, tt.version)
+		}
+		if c.Size != tt.version*4+17 {
+			t.Errorf("length %d: size = %d, want %d", tt.length, c.Size, tt.version*4+17)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package qrcode
+
+// exp and log tables for GF(256) over the QR polynomial x^8 + x^4 + x^3 + x^2 + 1
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// generator returns the coefficients, highest power first and without the
+// leading 1, of (x - a^0)(x - a^1)...(x - a^(degree-1))
+func generator(degree int) []byte {
+	poly := []byte{1}
+	for i := 0; i < degree; i++ {
+		next := make([]byte, len(poly)+1)
+		for j, coef := range poly {
+			next[j] ^= coef
+			next[j+1] ^= gfMul(coef, gfExp[i])
+		}
+		poly = next
+	}
+	return poly[1:]
+}
+
+// reedSolomon returns the error correction codewords for a block of data
+func reedSolomon(data []byte, degree int) []byte {
+	gen := generator(degree)
+	rem := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[degree-1] = 0
+		for i, coef := range gen {
+			rem[i] ^= gfMul(coef, factor)
+		}
+	}
+	return rem
+}
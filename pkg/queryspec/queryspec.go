@@ -0,0 +1,326 @@
+// Package queryspec turns list-endpoint query strings into parameterised SQL.
+//
+// Each resource declares a Spec listing which columns can be filtered, sorted
+// and searched. Requests use:
+//
+//	?filter[amount][gte]=100&filter[category][in]=bill,fund
+//	?filter[status]=success        (shorthand for [eq])
+//	?sort=-created_at,amount       (leading "-" sorts descending)
+//	?search=rent
+//
+// Only columns named in the Spec ever reach the SQL string; every value is
+// bound as an argument.
+package queryspec
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+type Operator string
+
+const (
+	Eq   Operator = "eq"
+	In   Operator = "in"
+	Gte  Operator = "gte"
+	Lte  Operator = "lte"
+	Like Operator = "like"
+)
+
+// Kind controls how filter values are validated and converted before binding
+type Kind int
+
+const (
+	Text Kind = iota
+	Integer
+	Number
+	// Date accepts YYYY-MM-DD; lte includes the whole day
+	Date
+)
+
+// Field is a filterable column
+type Field struct {
+	Column string
+	Kind   Kind
+	Ops    []Operator
+	// Values restricts eq/in to a fixed set, for enum columns
+	Values []string
+	// PrefixMatch makes like match from the start of the column instead of anywhere
+	PrefixMatch bool
+}
+
+// Alias maps a flat query param such as ?min_amount= onto a field and operator
+type Alias struct {
+	Field string
+	Op    Operator
+}
+
+// SortTerm is one column of an ORDER BY clause
+type SortTerm struct {
+	Column string
+	Desc   bool
+}
+
+// Spec declares what a resource allows callers to filter, sort and search on
+type Spec struct {
+	Filters map[string]Field
+	Aliases map[string]Alias
+	// Sorts maps the public sort name to its column
+	Sorts       map[string]string
+	DefaultSort []SortTerm
+	// TieBreaker is appended to every ORDER BY so pages are stable
+	TieBreaker string
+	// MaxSorts limits how many sort terms a caller may pass, 0 means no limit
+	MaxSorts int
+	Search   []string
+}
+
+// Query is the parsed, validated form of a request's query string
+type Query struct {
+	// Where is empty or starts with " AND " so it can follow an existing WHERE
+	Where string
+	Args  []interface{}
+	Sorts []SortTerm
+}
+
+// OrderBy renders the ORDER BY clause including the spec's tie-breaker
+func (q *Query) OrderBy() string {
+	terms := make([]string, 0, len(q.Sorts))
+	for _, s := range q.Sorts {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		terms = append(terms, s.Column+" "+dir)
+	}
+	if len(terms) == 0 {
+		return ""
+	}
+	return " ORDER BY " + strings.Join(terms, ", ")
+}
+
+var filterKey = regexp.MustCompile(`^filter\[([a-z_]+)\](?:\[([a-z]+)\])?$`)
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+// Parse validates values against the spec and builds the WHERE and ORDER BY parts
+func (s Spec) Parse(values url.Values) (*Query, error) {
+	q := &Query{}
+	var clauses []string
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values.Get(key)
+		if value == "" {
+			continue
+		}
+
+		var name string
+		var op Operator
+		if m := filterKey.FindStringSubmatch(key); m != nil {
+			name, op = m[1], Operator(m[2])
+			if op == "" {
+				op = Eq
+			}
+		} else if alias, ok := s.Aliases[key]; ok {
+			name, op = alias.Field, alias.Op
+		} else {
+			continue
+		}
+
+		field, ok := s.Filters[name]
+		if !ok {
+			return nil, invalid("cannot filter on %s", name)
+		}
+
+		clause, args, err := field.clause(name, op, value)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause)
+		q.Args = append(q.Args, args...)
+	}
+
+	if term := strings.TrimSpace(values.Get("search")); term != "" && len(s.Search) > 0 {
+		var ors []string
+		for _, col := range s.Search {
+			ors = append(ors, col+" LIKE ?")
+			q.Args = append(q.Args, "%"+escapeLike(term)+"%")
+		}
+		clauses = append(clauses, "("+strings.Join(ors, " OR ")+")")
+	}
+
+	if len(clauses) > 0 {
+		q.Where = " AND " + strings.Join(clauses, " AND ")
+	}
+
+	sorts, err := s.parseSort(values)
+	if err != nil {
+		return nil, err
+	}
+	q.Sorts = sorts
+
+	return q, nil
+}
+
+func (s Spec) parseSort(values url.Values) ([]SortTerm, error) {
+	raw := values.Get("sort")
+
+	// sortBy/sortOrder are the older single-column form
+	if raw == "" && values.Get("sortBy") != "" {
+		order := strings.ToLower(values.Get("sortOrder"))
+		if order != "" && order != "asc" && order != "desc" {
+			return nil, invalid("sortOrder must be asc or desc")
+		}
+		raw = values.Get("sortBy")
+		// the old form sorted newest first unless told otherwise
+		if order != "asc" {
+			raw = "-" + raw
+		}
+	}
+
+	var sorts []SortTerm
+	if raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			part = strings.TrimSpace(part)
+			desc := strings.HasPrefix(part, "-")
+			name := strings.TrimPrefix(part, "-")
+			column, ok := s.Sorts[name]
+			if !ok {
+				return nil, invalid("cannot sort by %s", name)
+			}
+			sorts = append(sorts, SortTerm{Column: column, Desc: desc})
+		}
+		if s.MaxSorts > 0 && len(sorts) > s.MaxSorts {
+			return nil, invalid("at most %d sort field(s) allowed", s.MaxSorts)
+		}
+	} else {
+		sorts = append(sorts, s.DefaultSort...)
+	}
+
+	if s.TieBreaker != "" && len(sorts) > 0 {
+		desc := sorts[len(sorts)-1].Desc
+		sorts = append(sorts, SortTerm{Column: s.TieBreaker, Desc: desc})
+	}
+
+	return sorts, nil
+}
+
+func (f Field) allows(op Operator) bool {
+	for _, o := range f.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Field) clause(name string, op Operator, raw string) (string, []interface{}, error) {
+	if !f.allows(op) {
+		return "", nil, invalid("operator %s is not supported for %s", op, name)
+	}
+
+	switch op {
+	case In:
+		parts := strings.Split(raw, ",")
+		args := make([]interface{}, 0, len(parts))
+		for _, p := range parts {
+			v, err := f.convert(name, strings.TrimSpace(p))
+			if err != nil {
+				return "", nil, err
+			}
+			args = append(args, v)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+		return f.Column + " IN (" + placeholders + ")", args, nil
+
+	case Like:
+		pattern := "%" + escapeLike(raw) + "%"
+		if f.PrefixMatch {
+			pattern = escapeLike(raw) + "%"
+		}
+		return f.Column + " LIKE ?", []interface{}{pattern}, nil
+
+	case Gte, Lte:
+		v, err := f.convert(name, raw)
+		if err != nil {
+			return "", nil, err
+		}
+		if op == Gte {
+			return f.Column + " >= ?", []interface{}{v}, nil
+		}
+		// an end date covers the whole day
+		if f.Kind == Date {
+			day, _ := time.Parse("2006-01-02", raw)
+			return f.Column + " < ?", []interface{}{day.AddDate(0, 0, 1).Format("2006-01-02 15:04:05")}, nil
+		}
+		return f.Column + " <= ?", []interface{}{v}, nil
+
+	default:
+		v, err := f.convert(name, raw)
+		if err != nil {
+			return "", nil, err
+		}
+		if f.Kind == Date {
+			day, _ := time.Parse("2006-01-02", raw)
+			return f.Column + " >= ? AND " + f.Column + " < ?",
+				[]interface{}{v, day.AddDate(0, 0, 1).Format("2006-01-02 15:04:05")}, nil
+		}
+		return f.Column + " = ?", []interface{}{v}, nil
+	}
+}
+
+func (f Field) convert(name, raw string) (interface{}, error) {
+	if len(f.Values) > 0 {
+		for _, allowed := range f.Values {
+			if raw == allowed {
+				return raw, nil
+			}
+		}
+		return nil, invalid("%s must be one of %s", name, strings.Join(f.Values, ", "))
+	}
+
+	switch f.Kind {
+	case Integer:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, invalid("%s must be a whole number", name)
+		}
+		return v, nil
+	case Number:
+		v, err := decimal.NewFromString(raw)
+		if err != nil {
+			return nil, invalid("%s must be a number", name)
+		}
+		return v, nil
+	case Date:
+		day, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return nil, invalid("%s must be in YYYY-MM-DD format", name)
+		}
+		return day.Format("2006-01-02 15:04:05"), nil
+	default:
+		return raw, nil
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
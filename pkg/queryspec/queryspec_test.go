@@ -0,0 +1,147 @@
+package queryspec
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+var testSpec = Spec{
+	Filters: map[string]Field{
+		"amount":    {Column: "amount", Kind: Number, Ops: []Operator{Eq, Gte, Lte}},
+		"group":     {Column: "group_id", Kind: Integer, Ops: []Operator{Eq, In}},
+		"status":    {Column: "status", Ops: []Operator{Eq, In}, Values: []string{"pending", "success"}},
+		"reference": {Column: "reference", Ops: []Operator{Eq, Like}, PrefixMatch: true},
+		"note":      {Column: "description", Ops: []Operator{Like}},
+		"created":   {Column: "created_at", Kind: Date, Ops: []Operator{Eq, Gte, Lte}},
+	},
+	Aliases: map[string]Alias{
+		"min_amount": {Field: "amount", Op: Gte},
+		"from":       {Field: "created", Op: Gte},
+	},
+	Sorts:       map[string]string{"created_at": "created_at", "amount": "amount"},
+	DefaultSort: []SortTerm{{Column: "created_at", Desc: true}},
+	TieBreaker:  "id",
+	MaxSorts:    2,
+	Search:      []string{"description", "reference"},
+}
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		where string
+		args  string
+	}{
+		{"no filters", "", "", "[]"},
+		{"shorthand eq", "filter[status]=success", " AND status = ?", "[success]"},
+		{"explicit operator", "filter[amount][gte]=100.50", " AND amount >= ?", "[100.5]"},
+		{"in list", "filter[group][in]=1, 2,3", " AND group_id IN (?, ?, ?)", "[1 2 3]"},
+		{"like escapes wildcards", `filter[note][like]=50%25_off\`, " AND description LIKE ?", `[%50\%\_off\\%]`},
+		{"prefix like", "filter[reference][like]=TXN_", " AND reference LIKE ?", `[TXN\_%]`},
+		{"date eq covers the day", "filter[created]=2024-01-31", " AND created_at >= ? AND created_at < ?", "[2024-01-31 00:00:00 2024-02-01 00:00:00]"},
+		{"date lte includes the day", "filter[created][lte]=2024-12-31", " AND created_at < ?", "[2025-01-01 00:00:00]"},
+		{"alias", "min_amount=10", " AND amount >= ?", "[10]"},
+		{"keys are applied in order", "filter[status]=pending&filter[amount][lte]=5", " AND amount <= ? AND status = ?", "[5 pending]"},
+		{"empty value is skipped", "filter[status]=", "", "[]"},
+		{"unrelated params are ignored", "page=2&limit=10", "", "[]"},
+		{"malformed filter key is ignored", "filter[amount) OR 1=1 --]=1", "", "[]"},
+		{"search", "search=%20rent%20", " AND (description LIKE ? OR reference LIKE ?)", "[%rent% %rent%]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			q, err := testSpec.Parse(values)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if q.Where != tt.where {
+				t.Errorf("Where = %q, want %q", q.Where, tt.where)
+			}
+			if got := fmt.Sprint(q.Args); got != tt.args {
+				t.Errorf("Args = %s, want %s", got, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"unknown field", "filter[password]=x"},
+		{"operator not allowed for field", "filter[amount][like]=1"},
+		{"unknown operator", "filter[amount][ne]=1"},
+		{"value outside enum", "filter[status]=failed"},
+		{"enum in list with bad member", "filter[status][in]=pending,failed"},
+		{"non integer", "filter[group]=1%3BDROP"},
+		{"non number", "filter[amount][gte]=abc"},
+		{"bad date", "filter[created][gte]=31-01-2024"},
+		{"bad alias value", "from=yesterday"},
+		{"unknown sort", "sort=password"},
+		{"too many sorts", "sort=amount,-created_at,amount"},
+		{"sort injection", "sort=amount%3BDROP%20TABLE%20users"},
+		{"bad legacy sort order", "sortBy=amount&sortOrder=sideways"},
+		{"unknown legacy sort", "sortBy=password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			if _, err := testSpec.Parse(values); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Parse() error = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		orderBy string
+	}{
+		{"default", "", " ORDER BY created_at DESC, id DESC"},
+		{"ascending", "sort=amount", " ORDER BY amount ASC, id ASC"},
+		{"descending", "sort=-amount", " ORDER BY amount DESC, id DESC"},
+		{"tie-breaker follows last term", "sort=-created_at,amount", " ORDER BY created_at DESC, amount ASC, id ASC"},
+		{"legacy defaults to descending", "sortBy=amount", " ORDER BY amount DESC, id DESC"},
+		{"legacy ascending", "sortBy=amount&sortOrder=ASC", " ORDER BY amount ASC, id ASC"},
+		{"legacy descending", "sortBy=amount&sortOrder=desc", " ORDER BY amount DESC, id DESC"},
+		{"sort wins over legacy", "sort=amount&sortBy=created_at", " ORDER BY amount ASC, id ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad test query: %v", err)
+			}
+			q, err := testSpec.Parse(values)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := q.OrderBy(); got != tt.orderBy {
+				t.Errorf("OrderBy() = %q, want %q", got, tt.orderBy)
+			}
+		})
+	}
+
+	// a spec without sorts or a default renders no ORDER BY at all
+	q, err := Spec{TieBreaker: "id"}.Parse(url.Values{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := q.OrderBy(); got != "" {
+		t.Errorf("OrderBy() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,9 @@
+package utils
+
+import "github.com/shopspring/decimal"
+
+// HasAtMostTwoDecimals reports whether amount can be stored to the kobo/cent.
+// Trailing zeros such as 10.500 are fine, 10.505 is not.
+func HasAtMostTwoDecimals(amount decimal.Decimal) bool {
+	return amount.Equal(amount.Round(2))
+}
@@ -0,0 +1,20 @@
+package utils
+
+var currencySymbols = map[string]string{
+	"NGN": "₦",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"GHS": "₵",
+	"KES": "KSh ",
+	"ZAR": "R",
+}
+
+// CurrencySymbol returns the symbol amounts in the currency are written with,
+// falling back to the code itself for currencies without one
+func CurrencySymbol(code string) string {
+	if symbol, ok := currencySymbols[code]; ok {
+		return symbol
+	}
+	return code + " "
+}
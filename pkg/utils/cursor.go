@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor packs the sort value and id of the last row on a page into an opaque cursor
+func EncodeCursor(sortValue string, id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sortValue + "|" + strconv.Itoa(id)))
+}
+
+// DecodeCursor unpacks a cursor produced by EncodeCursor
+func DecodeCursor(cursor string) (string, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+
+	idx := strings.LastIndex(string(raw), "|")
+	if idx < 0 {
+		return "", 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(raw[idx+1:]))
+	if err != nil {
+		return "", 0, ErrInvalidCursor
+	}
+
+	return string(raw[:idx]), id, nil
+}
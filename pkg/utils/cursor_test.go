@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortValue string
+		id        int
+	}{
+		{"timestamp", "2024-01-31 12:30:00", 42},
+		{"amount", "1500.25", 7},
+		{"empty sort value", "", 1},
+		{"sort value containing the separator", "a|b|c", 99},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortValue, id, err := DecodeCursor(EncodeCursor(tt.sortValue, tt.id))
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if sortValue != tt.sortValue || id != tt.id {
+				t.Errorf("DecodeCursor() = %q, %d, want %q, %d", sortValue, id, tt.sortValue, tt.id)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("2024-01-01|12"))},
+		{"no separator", encode("2024-01-01")},
+		{"missing id", encode("2024-01-01|")},
+		{"non numeric id", encode("2024-01-01|1 OR 1=1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -3,63 +3,10 @@ package utils
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"reflect"
 	"strings"
 )
 
-func isValidSortOrder(order string) bool {
-	return order == "asc" || order == "desc"
-}
-
-func isValidSortField(field string) bool {
-	validFields := map[string]bool{
-		"name":          true,
-		"description":   true,
-		"total_expense": true,
-	}
-	return validFields[field]
-}
-
-func AddSorting(r *http.Request, query string) string {
-	sortParams := r.URL.Query()["sortby"]
-	if len(sortParams) > 0 {
-		query += " ORDER BY"
-		for i, param := range sortParams {
-			parts := strings.Split(param, ":")
-			if len(parts) != 2 {
-				continue
-			}
-			field, order := parts[0], parts[1]
-			if !isValidSortField(field) || !isValidSortOrder(order) {
-				continue
-			}
-			if i > 0 {
-				query += ","
-			}
-			query += " " + field + " " + order
-		}
-	}
-	return query
-}
-
-func AddFilters(r *http.Request, query string, args []interface{}) (string, []interface{}) {
-	params := map[string]string{
-		"name":          "name",
-		"description":   "description",
-		"total_expense": "total_expense",
-	}
-
-	for param, dbField := range params {
-		value := r.URL.Query().Get(param)
-		if value != "" {
-			query += " AND " + dbField + " = ?"
-			args = append(args, value)
-		}
-	}
-	return query, args
-}
-
 func GenerateInsertQuery(tableName, model interface{}) string {
 	modelType := reflect.TypeOf(model)
 	var columns, placeholders string
@@ -5,8 +5,9 @@ import (
 	"time"
 )
 
-func SendDebtorReminderEmail(to, firstName string, amount string, groupName string, expenseTitle string, dueDate time.Time) error {
-	subject := fmt.Sprintf("💰 Reminder: You Still Owe ₦%s for '%s'", amount, expenseTitle)
+func SendDebtorReminderEmail(to, firstName string, currency string, amount string, groupName string, expenseTitle string, dueDate time.Time) error {
+	symbol := CurrencySymbol(currency)
+	subject := fmt.Sprintf("💰 Reminder: You Still Owe %s%s for '%s'", symbol, amount, expenseTitle)
 
 	body := fmt.Sprintf(`
 	<!DOCTYPE html>
@@ -93,12 +94,12 @@ func SendDebtorReminderEmail(to, firstName string, amount string, groupName stri
 			<div class="content">
 				<p class="message">
 					Hi %s,<br><br>
-					This is a friendly reminder that you still have an outstanding balance of ₦<b>%s</b> 
+					This is a friendly reminder that you still have an outstanding balance of %s<b>%s</b> 
 					for the shared expense <b>'%s'</b> in your group <b>%s</b>.
 				</p>
 
 				<div class="amount-box">
-					<h3>₦%s Due</h3>
+					<h3>%s%s Due</h3>
 					<p>Group: %s</p>
 					<p>Due Since: %s</p>
 				</div>
@@ -117,7 +118,7 @@ func SendDebtorReminderEmail(to, firstName string, amount string, groupName stri
 		</div>
 	</body>
 	</html>
-	`, firstName, amount, expenseTitle, groupName, amount, groupName, dueDate.Format("Jan 2, 2006"), time.Now().Year())
+	`, firstName, symbol, amount, expenseTitle, groupName, symbol, amount, groupName, dueDate.Format("Jan 2, 2006"), time.Now().Year())
 
 	return SendEmail(to, subject, body)
 }
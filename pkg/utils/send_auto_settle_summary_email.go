@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AutoSettleItem struct {
+	SplitID      int
+	GroupName    string
+	ExpenseTitle string
+	Amount       string
+}
+
+func SendAutoSettleSummaryEmail(to, firstName string, items []AutoSettleItem, total string, balance string) error {
+	subject := fmt.Sprintf("✅ Auto-Settle: ₦%s Paid From Your Wallet", total)
+
+	var rows strings.Builder
+	for _, item := range items {
+		rows.WriteString(fmt.Sprintf(`
+					<tr>
+						<td>#%d</td>
+						<td>%s</td>
+						<td>%s</td>
+						<td class="amount">₦%s</td>
+					</tr>`, item.SplitID, item.GroupName, item.ExpenseTitle, item.Amount))
+	}
+
+	body := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>Auto-Settle Summary</title>
+	<style>
+		body {
+			font-family: 'Segoe UI', Roboto, Arial, sans-serif;
+			background-color: #f6f8f7;
+			margin: 0;
+			padding: 0;
+			color: #333;
+		}
+		.container {
+			max-width: 520px;
+			margin: 25px auto;
+			background: #ffffff;
+			border-radius: 12px;
+			box-shadow: 0 4px 16px rgba(0, 0, 0, 0.08);
+			overflow: hidden;
+			border-top: 5px solid #0a4d3c;
+		}
+		.header {
+			background-color: #0a4d3c;
+			color: #ffffff;
+			text-align: center;
+			padding: 18px 12px;
+		}
+		.header h1 {
+			margin: 0;
+			font-size: 18px;
+			font-weight: 600;
+		}
+		.content {
+			padding: 20px 18px;
+		}
+		.message {
+			font-size: 14px;
+			line-height: 1.6;
+			color: #444;
+		}
+		table {
+			width: 100%%;
+			border-collapse: collapse;
+			margin: 16px 0;
+			font-size: 13px;
+		}
+		th {
+			text-align: left;
+			background: #f2fdf6;
+			color: #0a4d3c;
+			padding: 8px;
+			border-bottom: 1px solid #bfe7cb;
+		}
+		td {
+			padding: 8px;
+			border-bottom: 1px solid #eeeeee;
+		}
+		.amount {
+			text-align: right;
+			font-weight: 600;
+		}
+		.amount-box {
+			background: #f2fdf6;
+			border: 1px solid #bfe7cb;
+			border-radius: 8px;
+			padding: 12px 14px;
+			margin: 16px 0;
+			text-align: center;
+		}
+		.amount-box h3 {
+			margin: 0;
+			color: #0a4d3c;
+			font-size: 16px;
+			font-weight: 700;
+		}
+		.amount-box p {
+			margin: 6px 0 0;
+			font-size: 13px;
+			color: #555;
+		}
+		.footer {
+			background: #f0f6f2;
+			text-align: center;
+			padding: 14px;
+			font-size: 12px;
+			color: #777;
+			border-top: 1px solid #e5e5e5;
+		}
+		.brand {
+			color: #0a4d3c;
+			font-weight: bold;
+		}
+	</style>
+	</head>
+
+	<body>
+		<div class="container">
+			<div class="header">
+				<h1>Your Debts Were Auto-Settled 💚</h1>
+			</div>
+			<div class="content">
+				<p class="message">
+					Hi %s,<br><br>
+					As requested, we used your wallet balance to settle the following shared expenses.
+				</p>
+
+				<table>
+					<tr>
+						<th>Split</th>
+						<th>Group</th>
+						<th>Expense</th>
+						<th class="amount">Amount</th>
+					</tr>%s
+				</table>
+
+				<div class="amount-box">
+					<h3>₦%s Paid</h3>
+					<p>Wallet balance: ₦%s</p>
+					<p>Date: %s</p>
+				</div>
+
+				<p class="message">
+					You can turn auto-settle off or change your minimum balance from your preferences on <b>Qiyana Pay Buddy</b>.
+				</p>
+			</div>
+			<div class="footer">
+				&copy; %d <span class="brand">Qiyana Pay Buddy</span> — Smarter Sharing. Stronger Bonds.
+			</div>
+		</div>
+	</body>
+	</html>
+	`, firstName, rows.String(), total, balance, time.Now().Format("3:04 PM, Jan 2 2006"), time.Now().Year())
+
+	return SendEmail(to, subject, body)
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type DigestGroup struct {
+	Name     string
+	Expenses int
+	Share    string
+}
+
+type DigestCategory struct {
+	Name   string
+	Amount string
+}
+
+type MonthlyDigest struct {
+	Period        string
+	Inflow        string
+	Outflow       string
+	OwedToYou     string
+	YouOwe        string
+	Groups        []DigestGroup
+	TopCategories []DigestCategory
+}
+
+func SendMonthlyDigestEmail(to, firstName string, digest MonthlyDigest) error {
+	subject := fmt.Sprintf("📊 Your %s Spending Digest", digest.Period)
+
+	var groups strings.Builder
+	for _, g := range digest.Groups {
+		groups.WriteString(fmt.Sprintf(`
+					<tr>
+						<td>%s</td>
+						<td>%d</td>
+						<td class="amount">₦%s</td>
+					</tr>`, g.Name, g.Expenses, g.Share))
+	}
+	if len(digest.Groups) == 0 {
+		groups.WriteString(`
+					<tr>
+						<td colspan="3">No group expenses last month</td>
+					</tr>`)
+	}
+
+	var categories strings.Builder
+	for _, c := range digest.TopCategories {
+		categories.WriteString(fmt.Sprintf(`
+					<tr>
+						<td>%s</td>
+						<td class="amount">₦%s</td>
+					</tr>`, c.Name, c.Amount))
+	}
+	if len(digest.TopCategories) == 0 {
+		categories.WriteString(`
+					<tr>
+						<td colspan="2">No spending last month</td>
+					</tr>`)
+	}
+
+	body := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>Monthly Digest</title>
+	<style>
+		body {
+			font-family: 'Segoe UI', Roboto, Arial, sans-serif;
+			background-color: #f6f8f7;
+			margin: 0;
+			padding: 0;
+			color: #333;
+		}
+		.container {
+			max-width: 520px;
+			margin: 25px auto;
+			background: #ffffff;
+			border-radius: 12px;
+			box-shadow: 0 4px 16px rgba(0, 0, 0, 0.08);
+			overflow: hidden;
+			border-top: 5px solid #0a4d3c;
+		}
+		.header {
+			background-color: #0a4d3c;
+			color: #ffffff;
+			text-align: center;
+			padding: 18px 12px;
+		}
+		.header h1 {
+			margin: 0;
+			font-size: 18px;
+			font-weight: 600;
+		}
+		.content {
+			padding: 20px 18px;
+		}
+		.message {
+			font-size: 14px;
+			line-height: 1.6;
+			color: #444;
+		}
+		table {
+			width: 100%%;
+			border-collapse: collapse;
+			margin: 16px 0;
+			font-size: 13px;
+		}
+		th {
+			text-align: left;
+			background: #f2fdf6;
+			color: #0a4d3c;
+			padding: 8px;
+			border-bottom: 1px solid #bfe7cb;
+		}
+		td {
+			padding: 8px;
+			border-bottom: 1px solid #eeeeee;
+		}
+		.amount {
+			text-align: right;
+			font-weight: 600;
+		}
+		.amount-box {
+			background: #f2fdf6;
+			border: 1px solid #bfe7cb;
+			border-radius: 8px;
+			padding: 12px 14px;
+			margin: 16px 0;
+			text-align: center;
+		}
+		.amount-box h3 {
+			margin: 0;
+			color: #0a4d3c;
+			font-size: 16px;
+			font-weight: 700;
+		}
+		.amount-box p {
+			margin: 6px 0 0;
+			font-size: 13px;
+			color: #555;
+		}
+		.footer {
+			background: #f0f6f2;
+			text-align: center;
+			padding: 14px;
+			font-size: 12px;
+			color: #777;
+			border-top: 1px solid #e5e5e5;
+		}
+		.section {
+			margin: 20px 0 6px;
+			font-size: 14px;
+			font-weight: 600;
+			color: #0a4d3c;
+		}
+		.stats {
+			width: 100%%;
+			margin: 16px 0;
+		}
+		.stats td {
+			width: 50%%;
+			background: #f2fdf6;
+			border: 4px solid #ffffff;
+			border-radius: 8px;
+			padding: 12px;
+			text-align: center;
+		}
+		.stats .label {
+			display: block;
+			font-size: 12px;
+			color: #555;
+		}
+		.stats .value {
+			display: block;
+			margin-top: 4px;
+			font-size: 16px;
+			font-weight: 700;
+			color: #0a4d3c;
+		}
+		.brand {
+			color: #0a4d3c;
+			font-weight: bold;
+		}
+	</style>
+	</head>
+
+	<body>
+		<div class="container">
+			<div class="header">
+				<h1>Your %s Digest 📊</h1>
+			</div>
+			<div class="content">
+				<p class="message">
+					Hi %s,<br><br>
+					Here is how your money moved on <b>Qiyana Pay Buddy</b> last month.
+				</p>
+
+				<table class="stats">
+					<tr>
+						<td><span class="label">Money in</span><span class="value">₦%s</span></td>
+						<td><span class="label">Money out</span><span class="value">₦%s</span></td>
+					</tr>
+					<tr>
+						<td><span class="label">Owed to you</span><span class="value">₦%s</span></td>
+						<td><span class="label">You owe</span><span class="value">₦%s</span></td>
+					</tr>
+				</table>
+
+				<p class="section">Spending by group</p>
+				<table>
+					<tr>
+						<th>Group</th>
+						<th>Expenses</th>
+						<th class="amount">Your share</th>
+					</tr>%s
+				</table>
+
+				<p class="section">Top categories</p>
+				<table>
+					<tr>
+						<th>Category</th>
+						<th class="amount">Spent</th>
+					</tr>%s
+				</table>
+
+				<p class="message">
+					Don't want these emails? You can turn off the monthly digest from your preferences.
+				</p>
+			</div>
+			<div class="footer">
+				&copy; %d <span class="brand">Qiyana Pay Buddy</span> — Smarter Sharing. Stronger Bonds.
+			</div>
+		</div>
+	</body>
+	</html>
+	`, digest.Period, firstName, digest.Inflow, digest.Outflow, digest.OwedToYou, digest.YouOwe, groups.String(), categories.String(), time.Now().Year())
+
+	return SendEmail(to, subject, body)
+}
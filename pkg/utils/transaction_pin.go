@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+)
+
+var pinPattern = regexp.MustCompile(`^[0-9]{4,6}$`)
+
+// ValidatePinFormat checks that a transaction PIN is 4 to 6 digits
+func ValidatePinFormat(pin string) error {
+	if !pinPattern.MatchString(pin) {
+		return errors.New("pin must be 4 to 6 digits")
+	}
+	return nil
+}